@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/sync/errgroup"
+)
+
+// Commands for monitoring checks across many systems, unlike lvl system checks which works on one system.
+
+func init() {
+	// CHECKS
+	RootCmd.AddCommand(checksCmd)
+
+	// CHECKS STATUS
+	checksCmd.AddCommand(checksStatusCmd)
+	flags := checksStatusCmd.Flags()
+	flags.BoolVar(&checksStatusFailing, "failing", false, "Only show checks that are not OK")
+	flags.StringVar(&checksStatusGroup, "group", "", "Only show checks of systems in this systemgroup")
+	flags.StringSliceVar(&checksStatusSystems, "systems", nil, "Only show checks of these systems (e.g. web1,web2)")
+	flags.BoolVarP(&checksStatusWatch, "watch", "w", false, "Keep refreshing the dashboard")
+	flags.DurationVar(&checksStatusInterval, "interval", 30*time.Second, "With --watch, how often to refresh")
+	flags.IntVar(&checksStatusParallel, "parallel", 10, "Maximum amount of systems to fetch checks for at the same time")
+}
+
+// CHECKS
+var checksCmd = &cobra.Command{
+	Use:   "checks",
+	Short: "Monitoring checks across systems",
+}
+
+// Check statuses in order of importance, worst first. Other statuses go after these.
+var checkStatusOrder = []string{"critical", "unknown", "warning", "ok"}
+
+// Get how important a check status is, lower is worse.
+func checkStatusRank(status string) int {
+	idx := indexOf(checkStatusOrder, strings.ToLower(status))
+	if idx == -1 {
+		return len(checkStatusOrder)
+	}
+
+	return idx
+}
+
+// A check of a system, as shown by lvl checks status.
+type checkStatusRow struct {
+	System      string    `json:"system"`
+	SystemID    l27.IntID `json:"systemId"`
+	CheckID     l27.IntID `json:"checkId"`
+	CheckType   string    `json:"checkType"`
+	Status      string    `json:"status"`
+	Since       string    `json:"since"`
+	Information string    `json:"information"`
+}
+
+// CHECKS STATUS
+var checksStatusFailing bool
+var checksStatusGroup string
+var checksStatusSystems []string
+var checksStatusWatch bool
+var checksStatusInterval time.Duration
+var checksStatusParallel int
+var checksStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of monitoring checks on all systems",
+	Long: `Show the status of monitoring checks on all systems.
+Checks are fetched for every system (or those selected with --group/--systems) at the same time, and listed worst first.
+A summary of the amount of checks per status is written to stderr.`,
+	Example: `lvl checks status --failing
+lvl checks status --group webservers --watch
+lvl checks status --failing -o json`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if checksStatusParallel < 1 {
+			return fmt.Errorf("--parallel must be at least 1")
+		}
+
+		var systems []l27.System
+		var err error
+		if checksStatusGroup != "" || len(checksStatusSystems) != 0 {
+			systems, err = resolveSystemSelection(checksStatusGroup, checksStatusSystems)
+		} else {
+			systems, err = getAllPages("", Level27Client.SystemGetList)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !checksStatusWatch {
+			showChecksStatus(systems)
+			return nil
+		}
+
+		if checksStatusInterval < time.Second {
+			return fmt.Errorf("--interval must be at least a second")
+		}
+
+		for {
+			if viper.GetString("output") == "text" {
+				// Clear the terminal, so the dashboard stays in place.
+				fmt.Print("\033[H\033[2J")
+				fmt.Printf("Every %s, last updated %s\n\n", checksStatusInterval, time.Now().Format("15:04:05"))
+			}
+
+			showChecksStatus(systems)
+			time.Sleep(checksStatusInterval)
+		}
+	},
+}
+
+// Fetch the checks of systems and output them, worst first.
+func showChecksStatus(systems []l27.System) {
+	rows := getChecksStatus(systems)
+
+	counts := map[string]int{}
+	shown := []checkStatusRow{}
+	for _, row := range rows {
+		counts[strings.ToLower(row.Status)] += 1
+		if !checksStatusFailing || !strings.EqualFold(row.Status, "ok") {
+			shown = append(shown, row)
+		}
+	}
+
+	sort.SliceStable(shown, func(i, j int) bool {
+		rankI, rankJ := checkStatusRank(shown[i].Status), checkStatusRank(shown[j].Status)
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
+
+		return shown[i].System < shown[j].System
+	})
+
+	outputFormatTable(
+		shown,
+		[]string{"SYSTEM", "CHECKTYPE", "STATUS", "SINCE", "INFORMATION"},
+		[]string{"System", "CheckType", "Status", "Since", "Information"})
+
+	statuses := []string{}
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return checkStatusRank(statuses[i]) < checkStatusRank(statuses[j]) })
+
+	summary := []string{}
+	for _, status := range statuses {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%d checks on %d systems: %s\n", len(rows), len(systems), strings.Join(summary, ", "))
+}
+
+// Fetch the checks of systems concurrently.
+// Systems whose checks can't be fetched are reported on stderr and skipped.
+func getChecksStatus(systems []l27.System) []checkStatusRow {
+	perSystem := make([][]checkStatusRow, len(systems))
+
+	var group errgroup.Group
+	group.SetLimit(checksStatusParallel)
+	for i, system := range systems {
+		i, system := i, system
+		group.Go(func() error {
+			checks, err := getAllPages("", func(params l27.CommonGetParams) ([]l27.SystemCheckGet, error) {
+				return Level27Client.SystemCheckGetList(system.ID, params)
+			})
+
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get checks of %s: %s\n", system.Name, err.Error())
+				return nil
+			}
+
+			for _, check := range checks {
+				perSystem[i] = append(perSystem[i], checkStatusRow{
+					System:      system.Name,
+					SystemID:    system.ID,
+					CheckID:     check.ID,
+					CheckType:   check.CheckType,
+					Status:      check.Status,
+					Since:       utils.FormatUnixTime(check.DtLastStatusChanged),
+					Information: check.StatusInformation,
+				})
+			}
+
+			return nil
+		})
+	}
+
+	group.Wait()
+
+	rows := []checkStatusRow{}
+	for _, systemRows := range perSystem {
+		rows = append(rows, systemRows...)
+	}
+
+	return rows
+}
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Jeffail/gabs/v2"
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+)
+
+func init() {
+	// CHECKS PROFILE
+	checksCmd.AddCommand(checksProfileCmd)
+
+	// CHECKS PROFILE APPLY
+	checksProfileCmd.AddCommand(checksProfileApplyCmd)
+	flags := checksProfileApplyCmd.Flags()
+	flags.StringVar(&checksProfileApplyGroup, "group", "", "Apply the profile to all systems in this systemgroup")
+	flags.StringSliceVar(&checksProfileApplySystems, "systems", nil, "Apply the profile to these systems (e.g. web1,web2)")
+	flags.BoolVar(&checksProfileApplyRemoveExtra, "remove-extra", false, "Remove checks that are not in the profile")
+	flags.BoolVar(&checksProfileApplyDryRun, "dry-run", false, "Only show what would change")
+	flags.IntVar(&checksProfileApplyParallel, "parallel", 10, "Maximum amount of systems to work on at the same time")
+	addDeleteConfirmFlag(checksProfileApplyCmd)
+}
+
+// A change made by lvl checks profile apply, as shown in its output.
+type checksProfileResult struct {
+	System     string    `json:"system"`
+	SystemID   l27.IntID `json:"systemId"`
+	CheckID    l27.IntID `json:"checkId"`
+	CheckType  string    `json:"checkType"`
+	Action     string    `json:"action"`
+	Parameters string    `json:"parameters"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// The changes needed to bring a single system in line with a check profile.
+type checksProfilePlan struct {
+	System  l27.System
+	Changes []utils.CheckProfileChange
+	// Parameters of existing checks that are not at their default value, by check ID.
+	// These have to be sent again when updating a check.
+	Current map[int]map[string]interface{}
+	Error   error
+}
+
+// CHECKS PROFILE
+var checksProfileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Keep the checks of many systems in line with a profile",
+}
+
+// CHECKS PROFILE APPLY
+var checksProfileApplyGroup string
+var checksProfileApplySystems []string
+var checksProfileApplyRemoveExtra bool
+var checksProfileApplyDryRun bool
+var checksProfileApplyParallel int
+var checksProfileApplyCmd = &cobra.Command{
+	Use:   "apply <profile.yaml> (--group <systemgroup> | --systems <system,...>)",
+	Short: "Add and update checks on systems to match a profile",
+	Long: `Add and update checks on systems to match a profile.
+The profile is a YAML file listing check types and their parameters:
+
+  checks:
+    - type: http
+      parameters:
+        url: https://example.com/health
+        timeout: 10
+    - type: disk
+
+Checks that are missing from a system are added, and checks whose parameters differ from the profile are updated.
+Parameters that are not in the profile are left alone. With --remove-extra, checks that are not in the profile are removed.`,
+	Example: `lvl checks profile apply webservers.yaml --group webservers --dry-run
+lvl checks profile apply webservers.yaml --group webservers --remove-extra`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if checksProfileApplyParallel < 1 {
+			return fmt.Errorf("--parallel must be at least 1")
+		}
+
+		if checksProfileApplyGroup == "" && len(checksProfileApplySystems) == 0 {
+			return fmt.Errorf("a systemgroup (--group) or systems (--systems) to apply the profile to are required")
+		}
+
+		profile, err := utils.LoadCheckProfile(args[0])
+		if err != nil {
+			return err
+		}
+
+		err = validateCheckProfile(profile)
+		if err != nil {
+			return err
+		}
+
+		systems, err := resolveSystemSelection(checksProfileApplyGroup, checksProfileApplySystems)
+		if err != nil {
+			return err
+		}
+
+		plans := planChecksProfile(profile, systems)
+
+		if !checksProfileApplyDryRun && !optDeleteConfirmed {
+			removals := 0
+			for _, plan := range plans {
+				for _, change := range plan.Changes {
+					if change.Action == utils.CheckProfileRemove {
+						removals += 1
+					}
+				}
+			}
+
+			if removals != 0 && !confirmPrompt(fmt.Sprintf("Remove %d checks that are not in the profile?", removals)) {
+				return nil
+			}
+		}
+
+		var results []checksProfileResult
+		if checksProfileApplyDryRun {
+			results = checksProfileResults(plans)
+		} else {
+			results = applyChecksProfile(plans)
+		}
+
+		outputFormatTable(
+			results,
+			[]string{"SYSTEM", "CHECKTYPE", "CHECK", "ACTION", "PARAMETERS", "ERROR"},
+			[]string{"System", "CheckType", "CheckID", "Action", "Parameters", "Error"})
+
+		failed := 0
+		for _, result := range results {
+			if result.Error != "" {
+				failed += 1
+			}
+		}
+
+		if failed != 0 {
+			return fmt.Errorf("%d changes failed", failed)
+		}
+
+		return nil
+	},
+}
+
+// Check that the check types and parameters in a profile exist.
+func validateCheckProfile(profile utils.CheckProfile) error {
+	validated := map[string]bool{}
+	for _, check := range profile.Checks {
+		if validated[check.Type] {
+			continue
+		}
+
+		checktype, err := Level27Client.SystemCheckTypeGet(check.Type)
+		if err != nil {
+			return fmt.Errorf("invalid checktype '%s': %s", check.Type, err.Error())
+		}
+
+		possibleParameters := []string{}
+		for _, parameter := range checktype.ServiceType.Parameters {
+			possibleParameters = append(possibleParameters, parameter.Name)
+		}
+
+		validated[check.Type] = true
+		for _, other := range profile.Checks {
+			if other.Type != check.Type {
+				continue
+			}
+
+			for name := range other.Parameters {
+				if !sliceContains(possibleParameters, name) {
+					return fmt.Errorf("given parameter name is not valid for checktype %s: '%s'", check.Type, name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Work out the changes to make on each system concurrently.
+func planChecksProfile(profile utils.CheckProfile, systems []l27.System) []checksProfilePlan {
+	plans := make([]checksProfilePlan, len(systems))
+
+	var group errgroup.Group
+	group.SetLimit(checksProfileApplyParallel)
+	for i, system := range systems {
+		i, system := i, system
+		group.Go(func() error {
+			plans[i] = planChecksProfileSystem(profile, system)
+			return nil
+		})
+	}
+
+	group.Wait()
+	return plans
+}
+
+// Work out the changes to make on a single system.
+func planChecksProfileSystem(profile utils.CheckProfile, system l27.System) checksProfilePlan {
+	plan := checksProfilePlan{System: system, Current: map[int]map[string]interface{}{}}
+
+	checks, err := getAllPages("", func(params l27.CommonGetParams) ([]l27.SystemCheckGet, error) {
+		return Level27Client.SystemCheckGetList(system.ID, params)
+	})
+
+	if err != nil {
+		plan.Error = err
+		return plan
+	}
+
+	profileTypes := []string{}
+	for _, check := range profile.Checks {
+		profileTypes = append(profileTypes, check.Type)
+	}
+
+	existing := []utils.CheckProfileExisting{}
+	for _, check := range checks {
+		current := utils.CheckProfileExisting{ID: int(check.ID), Type: check.CheckType, Parameters: map[string]string{}}
+
+		// Only checks that can match the profile need their parameters.
+		if sliceContains(profileTypes, check.CheckType) {
+			details, err := Level27Client.SystemCheckDescribe(system.ID, check.ID)
+			if err != nil {
+				plan.Error = err
+				return plan
+			}
+
+			nonDefault := map[string]interface{}{}
+			for key, value := range details.CheckParameters.Map {
+				current.Parameters[key] = utils.CheckParameterString(value.Value)
+				if !value.Default {
+					nonDefault[key] = value.Value
+				}
+			}
+
+			plan.Current[current.ID] = nonDefault
+		}
+
+		existing = append(existing, current)
+	}
+
+	plan.Changes = utils.PlanCheckProfile(profile, existing, checksProfileApplyRemoveExtra)
+	return plan
+}
+
+// Turn plans into results without applying them, for --dry-run.
+func checksProfileResults(plans []checksProfilePlan) []checksProfileResult {
+	results := []checksProfileResult{}
+	for _, plan := range plans {
+		if plan.Error != nil {
+			results = append(results, checksProfileResult{System: plan.System.Name, SystemID: plan.System.ID, Action: "failed", Error: plan.Error.Error()})
+			continue
+		}
+
+		for _, change := range plan.Changes {
+			results = append(results, checksProfileResult{
+				System:     plan.System.Name,
+				SystemID:   plan.System.ID,
+				CheckID:    l27.IntID(change.CheckID),
+				CheckType:  change.Type,
+				Action:     change.Action,
+				Parameters: checksProfileChangeParameters(change),
+			})
+		}
+	}
+
+	return results
+}
+
+// Describe the parameters a change sets, for output.
+func checksProfileChangeParameters(change utils.CheckProfileChange) string {
+	names := change.Drifted
+	if change.Action == utils.CheckProfileAdd {
+		names = []string{}
+		for name := range change.Parameters {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+	}
+
+	parameters := []string{}
+	for _, name := range names {
+		parameters = append(parameters, fmt.Sprintf("%s=%s", name, utils.CheckParameterString(change.Parameters[name])))
+	}
+
+	return strings.Join(parameters, ", ")
+}
+
+// Apply plans on all systems concurrently.
+// Changes that fail don't stop the others, their errors are part of the results.
+func applyChecksProfile(plans []checksProfilePlan) []checksProfileResult {
+	results := checksProfileResults(plans)
+
+	var group errgroup.Group
+	group.SetLimit(checksProfileApplyParallel)
+
+	// Results are in the same order as the plans and their changes, with a single result for plans that failed.
+	i := 0
+	for _, plan := range plans {
+		if plan.Error != nil {
+			i += 1
+			continue
+		}
+
+		for _, change := range plan.Changes {
+			plan, change, result := plan, change, &results[i]
+			group.Go(func() error {
+				if err := applyChecksProfileChange(plan, change, result); err != nil {
+					result.Action = "failed"
+					result.Error = err.Error()
+				}
+
+				return nil
+			})
+			i += 1
+		}
+	}
+
+	group.Wait()
+	return results
+}
+
+// Apply a single change to a system.
+func applyChecksProfileChange(plan checksProfilePlan, change utils.CheckProfileChange, result *checksProfileResult) error {
+	systemID := plan.System.ID
+	checkID := l27.IntID(change.CheckID)
+
+	switch change.Action {
+	case utils.CheckProfileAdd:
+		jsonObjCheckPost := gabs.New()
+		jsonObjCheckPost.Set(change.Type, "checktype")
+		for name, value := range change.Parameters {
+			jsonObjCheckPost.Set(value, name)
+		}
+
+		check, err := Level27Client.SystemCheckAdd(systemID, jsonObjCheckPost)
+		if err != nil {
+			return err
+		}
+
+		result.CheckID = check.ID
+	case utils.CheckProfileUpdate:
+		// Like lvl system checks update: non-default parameters have to be sent again, or they are reset.
+		updateCheckJson := gabs.New()
+		updateCheckJson.Set(change.Type, "checktype")
+		for name, value := range plan.Current[change.CheckID] {
+			updateCheckJson.Set(value, name)
+		}
+
+		for name, value := range change.Parameters {
+			updateCheckJson.Set(value, name)
+		}
+
+		return Level27Client.SystemCheckUpdate(systemID, checkID, updateCheckJson)
+	case utils.CheckProfileRemove:
+		return Level27Client.SystemCheckDelete(systemID, checkID)
+	}
+
+	return nil
+}
@@ -23,12 +23,14 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/level27/l27-go"
 	"github.com/level27/lvl/utils"
+	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 )
 
@@ -48,6 +50,29 @@ func addCommonGetFlags(cmd *cobra.Command) {
 	pf.StringVarP(&optGetParameters.Filter, "filter", "f", optGetParameters.Filter, "How to filter API results?")
 }
 
+// Get all entities from a paginated list endpoint, by requesting pages until a partial page is returned.
+// filter is passed to the API like --filter on get commands.
+func getAllPages[T any](filter string, get func(l27.CommonGetParams) ([]T, error)) ([]T, error) {
+	const pageSize = 100
+
+	all := []T{}
+	for offset := int32(0); ; offset += pageSize {
+		page, err := get(l27.CommonGetParams{
+			PageableParams: l27.PageableParams{Limit: pageSize, Offset: offset},
+			Filter:         filter,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, page...)
+		if len(page) < pageSize {
+			return all, nil
+		}
+	}
+}
+
 // Common flag to skip deletion confirmation prompts. Add flag with addDeleteConfirmFlag
 var optDeleteConfirmed bool
 
@@ -150,6 +175,18 @@ func readArgFileSupported(arg string) (string, error) {
 	return arg, nil
 }
 
+// Directory for files lvl keeps besides its config, like user record templates.
+// This is the .lvl directory next to the config file (e.g. ~/.lvl).
+func lvlDataDir() string {
+	configFile := viper.ConfigFileUsed()
+	if configFile == "" {
+		home, _ := homedir.Dir()
+		configFile = filepath.Join(home, ".lvl.yaml")
+	}
+
+	return filepath.Join(filepath.Dir(configFile), ".lvl")
+}
+
 // Load JSON settings from arg-specified file and merge it with override settings from other args.
 func loadMergeSettings(fileName string, override map[string]interface{}) (map[string]interface{}, error) {
 	jsonSettings, err := loadSettings(fileName)
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+)
+
+// Contains offline tooling for DNS zone files, like lvl dns lint.
+
+// MAIN COMMAND
+var dnsCmd = &cobra.Command{
+	Use:   "dns",
+	Short: "Offline tools for working with DNS zone files",
+}
+
+func init() {
+	RootCmd.AddCommand(dnsCmd)
+
+	// LINT
+	dnsCmd.AddCommand(dnsLintCmd)
+	dnsLintCmd.Flags().StringVar(&dnsLintOrigin, "origin", "", "Origin of the zone (e.g. example.com), used if the zone file has no $ORIGIN directive")
+
+	// FMT
+	dnsCmd.AddCommand(dnsFmtCmd)
+	dnsFmtCmd.Flags().StringVar(&dnsFmtOrigin, "origin", "", "Origin of the zone (e.g. example.com), used if the zone file has no $ORIGIN directive")
+	dnsFmtCmd.Flags().BoolVar(&dnsFmtCheck, "check", false, "Don't write anything, exit with a non-zero status if any file is not formatted")
+	dnsFmtCmd.Flags().BoolVarP(&dnsFmtWrite, "write", "w", false, "Write the result back to the source file instead of stdout")
+}
+
+// Normalize a user-given origin (like "example.com") to an absolute, lower-case domain name.
+func dnsNormalizeOrigin(origin string) string {
+	if origin == "" {
+		return ""
+	}
+
+	origin = strings.ToLower(origin)
+	if !strings.HasSuffix(origin, ".") {
+		origin += "."
+	}
+
+	return origin
+}
+
+// DNS LINT
+var dnsLintOrigin string
+var dnsLintCmd = &cobra.Command{
+	Use:   "lint <zone file>",
+	Short: "Check a zone file for errors",
+	Long: `Check a zone file for errors, without contacting the API.
+Reports parse errors, CNAMEs coexisting with other records, MX/NS records pointing to CNAMEs,
+names outside the zone, duplicate records, malformed SRV/CAA/TLSA records,
+TXT strings that are too long and SPF records that need too many DNS lookups.
+The command exits with a non-zero status if any errors are found. Warnings do not affect the exit status.
+Pass '-' as file name to read from stdin.`,
+	Example: `lvl dns lint example.com.zone --origin example.com
+lvl dns lint example.com.zone -o json`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := openArgFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open input: %s", err.Error())
+		}
+
+		defer file.Close()
+
+		issues := utils.LintZone(file, dnsNormalizeOrigin(dnsLintOrigin))
+
+		outputFormatTable(
+			issues,
+			[]string{"LINE", "SEVERITY", "NAME", "TYPE", "MESSAGE"},
+			[]string{"Line", "Severity", "Name", "Type", "Message"})
+
+		errorCount := 0
+		for _, issue := range issues {
+			if issue.Severity == utils.ZoneLintError {
+				errorCount += 1
+			}
+		}
+
+		if errorCount != 0 {
+			return fmt.Errorf("found %d error(s) in %s", errorCount, args[0])
+		}
+
+		return nil
+	},
+}
+
+// DNS FMT
+var dnsFmtOrigin string
+var dnsFmtCheck bool
+var dnsFmtWrite bool
+var dnsFmtCmd = &cobra.Command{
+	Use:   "fmt <zone file> [zone file...]",
+	Short: "Rewrite zone files in a canonical layout",
+	Long: `Rewrite zone files in a canonical layout, without contacting the API.
+Records are written one per line with explicit owner names, sorted and aligned in columns.
+Names are made relative to $ORIGIN where possible and TTL values are written in seconds.
+Note that comments are not preserved.
+
+By default, the formatted zone is written to stdout. Use --write to update the files in place,
+or --check to only verify that files are formatted (e.g. in CI).
+Pass '-' as file name to read from stdin.`,
+	Example: `lvl dns fmt example.com.zone --origin example.com
+lvl dns fmt -w zones/*.zone
+lvl dns fmt --check zones/*.zone`,
+
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if dnsFmtCheck && dnsFmtWrite {
+			return fmt.Errorf("--check and --write cannot be used together")
+		}
+
+		unformatted := 0
+		for _, fileName := range args {
+			original, formatted, err := dnsFormatFile(fileName, dnsNormalizeOrigin(dnsFmtOrigin))
+			if err != nil {
+				return fmt.Errorf("%s: %s", fileName, err.Error())
+			}
+
+			switch {
+			case dnsFmtCheck:
+				if !bytes.Equal(original, formatted) {
+					fmt.Println(fileName)
+					unformatted += 1
+				}
+			case dnsFmtWrite && fileName != "-":
+				if bytes.Equal(original, formatted) {
+					continue
+				}
+
+				err = os.WriteFile(fileName, formatted, 0o644)
+				if err != nil {
+					return fmt.Errorf("failed to write %s: %s", fileName, err.Error())
+				}
+			default:
+				os.Stdout.Write(formatted)
+			}
+		}
+
+		if unformatted != 0 {
+			return fmt.Errorf("%d file(s) not formatted", unformatted)
+		}
+
+		return nil
+	},
+}
+
+// Read a zone file and format it, returning the original and formatted contents.
+func dnsFormatFile(fileName string, origin string) ([]byte, []byte, error) {
+	file, err := openArgFile(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer file.Close()
+
+	original, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contents := utils.ReadZone(bytes.NewReader(original), origin)
+	if contents.Origin == "" && len(contents.Errors) == 0 {
+		return nil, nil, fmt.Errorf("zone has no $ORIGIN, pass one with --origin")
+	}
+
+	var formatted bytes.Buffer
+	err = utils.FormatZone(&formatted, contents)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return original, formatted.Bytes(), nil
+}
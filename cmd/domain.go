@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"strings"
 
@@ -70,41 +71,22 @@ func init() {
 	settingString(domainUpdateCmd, domainUpdateSettings, "nameserverIpv63", "")
 	settingInt32(domainUpdateCmd, domainUpdateSettings, "ttl", "")
 	settingBool(domainUpdateCmd, domainUpdateSettings, "handleDns", "")
-	settingID(domainUpdateCmd, domainUpdateSettings, "domaincontactLicensee", "")
-	settingID(domainUpdateCmd, domainUpdateSettings, "domaincontactOnSite", "")
+	settingString(domainUpdateCmd, domainUpdateSettings, "domaincontactLicensee", "The ID or name of a domain contact with type licensee")
+	settingString(domainUpdateCmd, domainUpdateSettings, "domaincontactOnSite", "The ID or name of a domain contact with type onsite")
 	settingID(domainUpdateCmd, domainUpdateSettings, "organisation", "")
 
 	// --------------------------------------------------- ACCESS --------------------------------------------------------
 	addAccessCmds(domainCmd, "domains", resolveDomain)
 
-	/*
-		// --------------------------------------------------- NOTIFICATIONS --------------------------------------------------------
-		domainCmd.AddCommand(domainNotificationCmd)
-
-		// CREATE NOTIFICATION
-		domainNotificationCmd.AddCommand(domainNotificationsCreateCmd)
-		flags = domainNotificationsCreateCmd.Flags()
-		flags.StringVarP(&domainNotificationPostType, "type", "t", "", "The notification type")
-		flags.StringVarP(&domainNotificationPostGroup, "group", "g", "", "The notification group")
-		flags.StringVarP(&domainNotificationPostParams, "params", "p", "", "Additional parameters (json)")
-		flags.SortFlags = false
-		domainNotificationsCreateCmd.MarkFlagRequired("type")
-		domainNotificationsCreateCmd.MarkFlagRequired("group")
-
-		// GET NOTIFICATIONS
-		var notificationsOrderBy string
-		domainNotificationCmd.AddCommand(domainNotificationsGetCmd)
-		flags = domainNotificationsGetCmd.Flags()
-		flags.StringVarP(&notificationsOrderBy, "orderby", "", "", "The field you want to order the results on")
-		flags.SortFlags = false
-		addCommonGetFlags(domainNotificationsGetCmd)
-	*/
 	// --------------------------------------------------- BILLABLEITEMS --------------------------------------------------------
 	addBillingCmds(domainCmd, "domains", resolveDomain)
 
 	// --------------------------------------------------- AVAILABILITY/CHECK --------------------------------------------------------
 	// CHECK
 	domainCmd.AddCommand(domainCheckCmd)
+	domainCheckCmd.Flags().StringVar(&domainCheckFile, "file", "", "File with domain names to check, one per line. Use '-' to read from stdin")
+	domainCheckCmd.Flags().StringSliceVar(&domainCheckExtensions, "ext", nil, "Extensions to check every name with (e.g. be,nl,com)")
+	domainCheckCmd.Flags().IntVar(&domainCheckParallel, "parallel", 8, "Maximum amount of checks to run at the same time")
 
 	// --------------------------------------------------- JOB HISTORY --------------------------------------------------------
 	addJobCmds(domainCmd, "domain", resolveDomain)
@@ -114,7 +96,8 @@ func init() {
 }
 
 // flag vars needed for all post or put requests on Domain level [Domains/]
-var domainCreateType, domainCreateLicensee l27.IntID
+var domainCreateType l27.IntID
+var domainCreateLicensee, domainCreateContactOnSite string
 var domainCreateOrganisation string
 var domainCreateName string
 var domainCreateNs1, domainCreateNs2, domainCreateNs3, domainCreateNs4 string
@@ -125,7 +108,6 @@ var domainCreateEppCode, domainCreateAutoRecordTemplate string
 var domainCreateHandleDns, domainCreateAutoRecordTemplateRep bool
 var domainCreateExtraFields string
 var domainCreateAutoTeams, domainCreateExternalInfo, domainCreateAction string
-var domainCreateContactOnSite l27.IntID
 
 // common date used for Post operations at /Domains
 func addDomainCommonPostFlags(cmd *cobra.Command) {
@@ -134,7 +116,7 @@ func addDomainCommonPostFlags(cmd *cobra.Command) {
 	command.StringVarP(&domainCreateName, "name", "n", "", "the name of the domain (REQUIRED)")
 	command.Int32VarP(&domainCreateType, "type", "t", 0, "the type of the domain")
 	command.MarkHidden("type")
-	command.Int32VarP(&domainCreateLicensee, "licensee", "l", 0, "The unique identifier of a domaincontact with type licensee")
+	command.StringVarP(&domainCreateLicensee, "licensee", "l", "", "The ID or name of a domain contact with type licensee")
 	command.StringVar(&domainCreateOrganisation, "organisation", "", "The organisation that will own the new domain.")
 
 	command.StringVarP(&domainCreateNs1, "nameserver1", "", "", "Nameserver")
@@ -157,7 +139,7 @@ func addDomainCommonPostFlags(cmd *cobra.Command) {
 	command.BoolVarP(&domainCreateHandleDns, "handleDns", "", true, "should dns be handled by lvl27")
 	command.StringVarP(&domainCreateExtraFields, "extra fields", "", "", "extra fields (json, non-editable)")
 
-	command.Int32VarP(&domainCreateContactOnSite, "domaincontactOnsite", "", 0, "the unique id of a domaincontact with type onsite")
+	command.StringVarP(&domainCreateContactOnSite, "domaincontactOnsite", "", "", "The ID or name of a domain contact with type onsite")
 
 	// command.StringVarP(&domainCreateAutoRecordTemplate, "autorecordTemplate", "", "", "AutorecordTemplate")
 	// command.BoolVarP(&domainCreateAutoRecordTemplateRep, "autorecordTemplateReplace", "", false, "autorecordTemplate replace")
@@ -222,6 +204,13 @@ var domainGetCmd = &cobra.Command{
 }
 
 // DESCRIBE DOMAIN (get detailed info from specific domain) - [lvl domain describe <id>]
+
+// Domain with the extra info shown by describe.
+type domainDescribe struct {
+	l27.Domain
+	DsRecords []domainDnssecDsRecord `json:"dsRecords"`
+}
+
 var domainDescribeCmd = &cobra.Command{
 	Use:   "describe",
 	Short: "Get detailed info about a domain",
@@ -251,7 +240,13 @@ var domainDescribeCmd = &cobra.Command{
 			}
 		}
 
-		outputFormatTemplate(domain, "templates/domain.tmpl")
+		// DS records are only extra information, show the rest of the domain if they can't be fetched.
+		dsRecords, err := getDomainDsRecords(domainID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get DS records: %s\n", err.Error())
+		}
+
+		outputFormatTemplate(domainDescribe{Domain: domain, DsRecords: dsRecords}, "templates/domain.tmpl")
 		return nil
 	},
 }
@@ -331,8 +326,6 @@ func getDomainRequestData() (l27.DomainRequest, error) {
 		Handledns:                 domainCreateHandleDns,
 		ExtraFields:               domainCreateExtraFields,
 		Domaintype:                domainCreateType,
-		Domaincontactlicensee:     &domainCreateLicensee,
-		DomainContactOnSite:       &domainCreateContactOnSite,
 		Organisation:              organisationID,
 		AutoRecordTemplate:        domainCreateAutoRecordTemplate,
 		AutoRecordTemplateReplace: domainCreateAutoRecordTemplateRep,
@@ -344,12 +337,22 @@ func getDomainRequestData() (l27.DomainRequest, error) {
 		ExternalInfo: &domainCreateExternalInfo,
 	}
 
-	if *requestData.DomainContactOnSite == 0 {
-		requestData.DomainContactOnSite = nil
+	if domainCreateLicensee != "" {
+		licenseeID, err := resolveDomainContact(domainCreateLicensee, "licensee")
+		if err != nil {
+			return l27.DomainRequest{}, err
+		}
+
+		requestData.Domaincontactlicensee = &licenseeID
 	}
 
-	if *requestData.Domaincontactlicensee == 0 {
-		requestData.Domaincontactlicensee = nil
+	if domainCreateContactOnSite != "" {
+		onSiteID, err := resolveDomainContact(domainCreateContactOnSite, "onsite")
+		if err != nil {
+			return l27.DomainRequest{}, err
+		}
+
+		requestData.DomainContactOnSite = &onSiteID
 	}
 
 	if requestData.Domaintype == 0 {
@@ -381,20 +384,32 @@ func splitDomainName(domain string) (string, string) {
 // Gets the domain type extension for a full domain name.
 func getDomainTypeForDomain(domain string) (string, string, l27.IntID, error) {
 	name, extension := splitDomainName(domain)
-	res, err := Level27Client.Extension()
+	domainTypes, err := getDomainTypes()
 	if err != nil {
 		return "", "", 0, err
 	}
 
+	return name, extension, domainTypes[extension], nil
+}
+
+// Gets the IDs of all domain types, by extension.
+func getDomainTypes() (map[string]l27.IntID, error) {
+	res, err := Level27Client.Extension()
+	if err != nil {
+		return nil, err
+	}
+
+	domainTypes := map[string]l27.IntID{}
 	for _, provider := range res {
 		for _, domainType := range provider.Domaintypes {
-			if domainType.Extension == extension {
-				return name, extension, domainType.ID, nil
+			// Several providers may offer the same extension, use the first one.
+			if _, ok := domainTypes[domainType.Extension]; !ok {
+				domainTypes[domainType.Extension] = domainType.ID
 			}
 		}
 	}
 
-	return name, extension, 0, nil
+	return domainTypes, nil
 }
 
 // CREATE DOMAIN [lvl domain create (action:create/none)]
@@ -499,65 +514,37 @@ var domainUpdateCmd = &cobra.Command{
 			fmt.Println("No options specified!")
 		}
 
-		Level27Client.DomainUpdate(domainID, domainUpdateSettings)
-		return nil
-	},
-}
-
-// --------------------------------------------------- NOTIFICATIONS --------------------------------------------------------
-/*
-// MAIN COMMAND
-var domainNotificationCmd = &cobra.Command{
-	Use:   "notifications",
-	Short: "Manage domain notifications",
-}
-
-// CREATE NOTIFICATION
-var domainNotificationPostType, domainNotificationPostGroup, domainNotificationPostParams string
-
-var domainNotificationsCreateCmd = &cobra.Command{
-	Use:   "create [domain] [flags]",
-	Short: "Send a notification for a domain",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		id, err := strconv.Atoi(args[0])
-		if err != nil {
-			log.Fatal("no valid domain ID")
-		}
-
-		Level27Client.DomainNotificationAdd(id, DomainNotificationPostRequest{
-			Type:   domainNotificationPostType,
-			Group:  domainNotificationPostGroup,
-			Params: domainNotificationPostParams,
-		})
-
-	},
-}
-
-// GET NOTIFICATIONS
-var domainNotificationsGetCmd = &cobra.Command{
-	Use:   "get",
-	Short: "get a list of all notifications from a domain",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		id, err := strconv.Atoi(args[0])
-		if err != nil {
-			log.Fatal("no valid domain ID")
+		for setting, contactType := range map[string]string{"domaincontactLicensee": "licensee", "domaincontactOnSite": "onsite"} {
+			if value, ok := domainUpdateSettings[setting]; ok {
+				domainUpdateSettings[setting], err = resolveDomainContact(fmt.Sprint(value), contactType)
+				if err != nil {
+					return err
+				}
+			}
 		}
 
-		notifications := Level27Client.DomainNotificationGet(id)
-		fmt.Print(notifications)
-
+		Level27Client.DomainUpdate(domainID, domainUpdateSettings)
+		return nil
 	},
 }
-*/
 
 // ---------------------------------------------- CHECK / AVAILABILITY ------------------------------------------------
 var domainCheckCmd = &cobra.Command{
-	Use:   "check [domain name]",
+	Use:   "check [domain name...]",
 	Short: "Check availability of a domain",
-	Args:  cobra.ExactArgs(1),
+	Long: `Check availability of a domain.
+Multiple domains can be checked at once by passing multiple names, names from a file with --file,
+or by combining names with multiple extensions with --ext. Names that already have an extension are checked as-is.
+When checking multiple domains, the availability and price of every domain is listed in a table.`,
+	Example: `lvl domain check example.be
+lvl domain check example.be example.nl
+lvl domain check --file names.txt --ext be,nl,com,eu -o csv`,
+
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 || domainCheckFile != "" || len(domainCheckExtensions) != 0 {
+			return domainCheckBulk(args)
+		}
+
 		domain := args[0]
 		name, extension := splitDomainName(domain)
 
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+// Bulk availability checks for lvl domain check.
+
+var domainCheckFile string
+var domainCheckExtensions []string
+var domainCheckParallel int
+
+// Result of checking a single domain.
+type domainCheckResult struct {
+	Domain string `json:"domain"`
+	Status string `json:"status"`
+	// Price of the first product for the domain, e.g. registration.
+	Price  string `json:"price"`
+	Period string `json:"period"`
+	Error  string `json:"error,omitempty"`
+}
+
+func domainCheckBulk(args []string) error {
+	names := append([]string{}, args...)
+	if domainCheckFile != "" {
+		fileNames, err := domainCheckReadNames(domainCheckFile)
+		if err != nil {
+			return err
+		}
+
+		names = append(names, fileNames...)
+	}
+
+	if len(names) == 0 {
+		return fmt.Errorf("no domain names given")
+	}
+
+	if domainCheckParallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+
+	domainTypes, err := getDomainTypes()
+	if err != nil {
+		return err
+	}
+
+	domains, err := domainCheckCombine(names, domainCheckExtensions, domainTypes)
+	if err != nil {
+		return err
+	}
+
+	results := make([]domainCheckResult, len(domains))
+
+	var group errgroup.Group
+	group.SetLimit(domainCheckParallel)
+	for i, domain := range domains {
+		i, domain := i, domain
+		group.Go(func() error {
+			results[i] = domainCheckSingle(domain)
+			return nil
+		})
+	}
+
+	group.Wait()
+
+	outputFormatTable(
+		results,
+		[]string{"DOMAIN", "STATUS", "PRICE", "PERIOD", "ERROR"},
+		[]string{"Domain", "Status", "Price", "Period", "Error"})
+
+	return nil
+}
+
+// Read domain names from a file, one per line. Empty lines and lines starting with # are skipped.
+func domainCheckReadNames(fileName string) ([]string, error) {
+	file, err := openArgFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	names := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		names = append(names, line)
+	}
+
+	return names, scanner.Err()
+}
+
+// Get the full list of domains to check.
+// Names without extension are combined with every extension, all extensions must be known domain types.
+func domainCheckCombine(names []string, extensions []string, domainTypes map[string]l27.IntID) ([]string, error) {
+	normalized := []string{}
+	for _, extension := range extensions {
+		extension = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(extension), "."))
+		if _, ok := domainTypes[extension]; !ok {
+			return nil, fmt.Errorf("unknown extension: '%s'", extension)
+		}
+
+		normalized = append(normalized, extension)
+	}
+
+	domains := []string{}
+	seen := map[string]bool{}
+	add := func(domain string) {
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if strings.Contains(name, ".") {
+			_, extension := splitDomainName(name)
+			if _, ok := domainTypes[extension]; !ok {
+				return nil, fmt.Errorf("unknown extension for '%s': '%s'", name, extension)
+			}
+
+			add(name)
+			continue
+		}
+
+		if len(normalized) == 0 {
+			return nil, fmt.Errorf("'%s' has no extension, pass extensions to check with --ext", name)
+		}
+
+		for _, extension := range normalized {
+			add(name + "." + extension)
+		}
+	}
+
+	return domains, nil
+}
+
+func domainCheckSingle(domain string) domainCheckResult {
+	result := domainCheckResult{Domain: domain}
+
+	name, extension := splitDomainName(domain)
+	status, err := Level27Client.DomainCheck(name, extension)
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Status = status.Status
+	if len(status.Products) != 0 && len(status.Products[0].Prices) != 0 {
+		prices := status.Products[0].Prices
+		sort.SliceStable(prices, func(i, j int) bool { return prices[i].Period < prices[j].Period })
+
+		price, err := utils.FormatCurrency(prices[0].Currency, prices[0].Price)
+		if err != nil {
+			price = fmt.Sprintf("%s %s", prices[0].Price, prices[0].Currency)
+		}
+
+		result.Price = price
+		result.Period = fmt.Sprintf("%v months", prices[0].Period)
+	}
+
+	return result
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// DOMAIN CONTACT
+	domainCmd.AddCommand(domainContactCmd)
+
+	// DOMAIN CONTACT GET
+	domainContactCmd.AddCommand(domainContactGetCmd)
+	addCommonGetFlags(domainContactGetCmd)
+
+	// DOMAIN CONTACT DESCRIBE
+	domainContactCmd.AddCommand(domainContactDescribeCmd)
+
+	// DOMAIN CONTACT CREATE
+	domainContactCmd.AddCommand(domainContactCreateCmd)
+	flags := domainContactCreateCmd.Flags()
+	flags.StringVarP(&domainContactCreate.Type, "type", "t", "licensee", "Type of the contact. Either 'licensee' or 'onsite'")
+	flags.StringVar(&domainContactCreate.FirstName, "firstName", "", "First name")
+	flags.StringVar(&domainContactCreate.LastName, "lastName", "", "Last name")
+	flags.StringVar(&domainContactCreate.OrganisationName, "organisationName", "", "Name of the company, if the contact is not a person")
+	flags.StringVar(&domainContactCreate.Street, "street", "", "Street")
+	flags.StringVar(&domainContactCreate.HouseNumber, "houseNumber", "", "House number")
+	flags.StringVar(&domainContactCreate.Zip, "zip", "", "Postal code")
+	flags.StringVar(&domainContactCreate.City, "city", "", "City")
+	flags.StringVar(&domainContactCreate.State, "state", "", "State or province")
+	flags.StringVar(&domainContactCreate.Country, "country", "", "Country code (e.g. BE)")
+	flags.StringVar(&domainContactCreate.Phone, "phone", "", "Phone number (e.g. +32.123456789)")
+	flags.StringVar(&domainContactCreate.Fax, "fax", "", "Fax number")
+	flags.StringVar(&domainContactCreate.Email, "email", "", "Email address")
+	flags.StringVar(&domainContactCreate.TaxNumber, "taxNumber", "", "VAT number of the company")
+	flags.StringVar(&domainContactCreateOrganisation, "organisation", "", "The organisation that will own the new contact")
+	flags.SortFlags = false
+	domainContactCreateCmd.MarkFlagRequired("firstName")
+	domainContactCreateCmd.MarkFlagRequired("lastName")
+	domainContactCreateCmd.MarkFlagRequired("street")
+	domainContactCreateCmd.MarkFlagRequired("houseNumber")
+	domainContactCreateCmd.MarkFlagRequired("zip")
+	domainContactCreateCmd.MarkFlagRequired("city")
+	domainContactCreateCmd.MarkFlagRequired("country")
+	domainContactCreateCmd.MarkFlagRequired("phone")
+	domainContactCreateCmd.MarkFlagRequired("email")
+	domainContactCreateCmd.MarkFlagRequired("organisation")
+
+	// DOMAIN CONTACT UPDATE
+	domainContactCmd.AddCommand(domainContactUpdateCmd)
+	settingsFileFlag(domainContactUpdateCmd)
+	settingString(domainContactUpdateCmd, updateSettings, "firstName", "New first name")
+	settingString(domainContactUpdateCmd, updateSettings, "lastName", "New last name")
+	settingString(domainContactUpdateCmd, updateSettings, "organisationName", "New company name")
+	settingString(domainContactUpdateCmd, updateSettings, "street", "New street")
+	settingString(domainContactUpdateCmd, updateSettings, "houseNumber", "New house number")
+	settingString(domainContactUpdateCmd, updateSettings, "zip", "New postal code")
+	settingString(domainContactUpdateCmd, updateSettings, "city", "New city")
+	settingString(domainContactUpdateCmd, updateSettings, "state", "New state or province")
+	settingString(domainContactUpdateCmd, updateSettings, "country", "New country code")
+	settingString(domainContactUpdateCmd, updateSettings, "phone", "New phone number")
+	settingString(domainContactUpdateCmd, updateSettings, "fax", "New fax number")
+	settingString(domainContactUpdateCmd, updateSettings, "email", "New email address")
+	settingString(domainContactUpdateCmd, updateSettings, "taxNumber", "New VAT number")
+
+	// DOMAIN CONTACT DELETE
+	domainContactCmd.AddCommand(domainContactDeleteCmd)
+	addDeleteConfirmFlag(domainContactDeleteCmd)
+}
+
+// Resolve the integer ID of a domain contact, from a commandline-passed argument.
+// Returns ID if it's a numeric ID, otherwise resolves by name.
+// If contactType is not empty, only contacts of that type (licensee or onsite) are considered when resolving by name.
+func resolveDomainContact(arg string, contactType string) (l27.IntID, error) {
+	id, err := l27.ParseID(arg)
+	if err == nil {
+		return id, nil
+	}
+
+	options, err := Level27Client.DomainContactsLookup(arg)
+	if err != nil {
+		return 0, err
+	}
+
+	if contactType != "" {
+		filtered := []l27.DomainContact{}
+		for _, contact := range options {
+			if contact.Type == contactType {
+				filtered = append(filtered, contact)
+			}
+		}
+
+		options = filtered
+	}
+
+	res, err := resolveShared(
+		options,
+		arg,
+		"domain contact",
+		func(contact l27.DomainContact) string {
+			return fmt.Sprintf("%s (%s, %d)", domainContactDisplayName(contact), contact.Type, contact.ID)
+		})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.ID, nil
+}
+
+// Get the display name for a domain contact.
+// This is the full name of the person, followed by the company if there is one.
+func domainContactDisplayName(contact l27.DomainContact) string {
+	if contact.OrganisationName == "" {
+		return contact.Fullname
+	}
+
+	return fmt.Sprintf("%s - %s", contact.Fullname, contact.OrganisationName)
+}
+
+// DOMAIN CONTACT
+var domainContactCmd = &cobra.Command{
+	Use:   "contact",
+	Short: "Commands to manage domain contacts",
+	Long: `Commands to manage domain contacts.
+Domain contacts are the licensee and onsite contacts used when registering or transferring a domain.
+Contacts can be referred to by ID or by name, e.g. 'lvl domain create --licensee "John Doe"'.`,
+}
+
+// DOMAIN CONTACT GET
+var domainContactGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Get domain contacts",
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contacts, err := resolveGets(
+			args,
+			Level27Client.DomainContactsLookup,
+			Level27Client.DomainContactsGetSingle,
+			Level27Client.DomainContactsGetList)
+
+		if err != nil {
+			return err
+		}
+
+		outputFormatTableFuncs(
+			contacts,
+			[]string{"ID", "NAME", "TYPE", "EMAIL", "COUNTRY", "STATUS"},
+			[]interface{}{
+				"ID",
+				domainContactDisplayName,
+				"Type",
+				"Email",
+				func(c l27.DomainContact) string { return c.Country.Name },
+				"Status",
+			})
+
+		return nil
+	},
+}
+
+// DOMAIN CONTACT DESCRIBE
+var domainContactDescribeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Get detailed info about a domain contact",
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contactID, err := resolveDomainContact(args[0], "")
+		if err != nil {
+			return err
+		}
+
+		contact, err := Level27Client.DomainContactsGetSingle(contactID)
+		if err != nil {
+			return err
+		}
+
+		outputFormatTemplate(contact, "templates/domainContact.tmpl")
+		return nil
+	},
+}
+
+// DOMAIN CONTACT CREATE
+var domainContactCreate l27.DomainContactRequest
+var domainContactCreateOrganisation string
+
+var domainContactCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a new domain contact",
+	Example: `lvl domain contact create --organisation MyOrg --firstName John --lastName Doe \
+  --street Kerkstraat --houseNumber 1 --zip 9000 --city Gent --country BE \
+  --phone +32.123456789 --email john@example.com`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if domainContactCreate.Type != "licensee" && domainContactCreate.Type != "onsite" {
+			return fmt.Errorf("invalid contact type: '%s'. Must be 'licensee' or 'onsite'", domainContactCreate.Type)
+		}
+
+		org, err := resolveOrganisation(domainContactCreateOrganisation)
+		if err != nil {
+			return err
+		}
+
+		domainContactCreate.Organisation = org
+
+		contact, err := Level27Client.DomainContactsCreate(domainContactCreate)
+		if err != nil {
+			return err
+		}
+
+		outputFormatTemplate(contact, "templates/entities/domainContact/create.tmpl")
+		return nil
+	},
+}
+
+// DOMAIN CONTACT UPDATE
+var domainContactUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update settings on a domain contact",
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := loadMergeSettings(updateSettingsFile, updateSettings)
+		if err != nil {
+			return err
+		}
+
+		contactID, err := resolveDomainContact(args[0], "")
+		if err != nil {
+			return err
+		}
+
+		contact, err := Level27Client.DomainContactsGetSingle(contactID)
+		if err != nil {
+			return err
+		}
+
+		contactPut := l27.DomainContactRequest{
+			Type:             contact.Type,
+			FirstName:        contact.FirstName,
+			LastName:         contact.LastName,
+			OrganisationName: contact.OrganisationName,
+			Street:           contact.Street,
+			HouseNumber:      contact.HouseNumber,
+			Zip:              contact.Zip,
+			City:             contact.City,
+			State:            contact.State,
+			Country:          contact.Country.ID,
+			Phone:            contact.Phone,
+			Fax:              contact.Fax,
+			Email:            contact.Email,
+			TaxNumber:        contact.TaxNumber,
+			Organisation:     contact.Organisation.ID,
+		}
+
+		data := utils.RoundTripJson(contactPut).(map[string]interface{})
+		data = mergeMaps(data, settings)
+
+		err = Level27Client.DomainContactsUpdate(contactID, data)
+		if err != nil {
+			return err
+		}
+
+		outputFormatTemplate(nil, "templates/entities/domainContact/update.tmpl")
+		return nil
+	},
+}
+
+// DOMAIN CONTACT DELETE
+var domainContactDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete a domain contact",
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		contactID, err := resolveDomainContact(args[0], "")
+		if err != nil {
+			return err
+		}
+
+		if !optDeleteConfirmed {
+			contact, err := Level27Client.DomainContactsGetSingle(contactID)
+			if err != nil {
+				return err
+			}
+
+			if !confirmPrompt(fmt.Sprintf("Delete domain contact %s (%d)?", domainContactDisplayName(contact), contactID)) {
+				return nil
+			}
+		}
+
+		err = Level27Client.DomainContactsDelete(contactID)
+		if err != nil {
+			return err
+		}
+
+		outputFormatTemplate(nil, "templates/entities/domainContact/delete.tmpl")
+		return nil
+	},
+}
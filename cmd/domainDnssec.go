@@ -0,0 +1,542 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// DNSSEC
+	domainCmd.AddCommand(domainDnssecCmd)
+
+	// DNSSEC STATUS
+	domainDnssecCmd.AddCommand(domainDnssecStatusCmd)
+	addDomainDnssecQueryFlags(domainDnssecStatusCmd)
+
+	// DNSSEC ENABLE/DISABLE
+	domainDnssecCmd.AddCommand(domainDnssecEnableCmd)
+	domainDnssecCmd.AddCommand(domainDnssecDisableCmd)
+	addDeleteConfirmFlag(domainDnssecDisableCmd)
+
+	// DNSSEC DS
+	domainDnssecCmd.AddCommand(domainDnssecDsCmd)
+
+	// DNSSEC DS GET
+	domainDnssecDsCmd.AddCommand(domainDnssecDsGetCmd)
+
+	// DNSSEC DS COMPUTE
+	domainDnssecDsCmd.AddCommand(domainDnssecDsComputeCmd)
+	addDomainDnssecQueryFlags(domainDnssecDsComputeCmd)
+	addDomainDnssecDnskeyFlags(domainDnssecDsComputeCmd)
+	domainDnssecDsComputeCmd.Flags().BoolVar(&domainDnssecDsComputeAll, "all-keys", false, "Also compute DS records for zone signing keys")
+
+	// DNSSEC DS CREATE
+	domainDnssecDsCmd.AddCommand(domainDnssecDsCreateCmd)
+	addDomainDnssecDnskeyFlags(domainDnssecDsCreateCmd)
+	domainDnssecDsCreateCmd.Flags().StringVar(&domainDnssecDsCreateName, "name", "", "Name of the delegated subdomain to create the DS records for, relative to the domain")
+	domainDnssecDsCreateCmd.Flags().StringArrayVar(&domainDnssecDsCreateData, "ds", nil, "DS record data to create, e.g. '60485 13 2 D4B7...'. Can be given multiple times")
+	domainDnssecDsCreateCmd.MarkFlagRequired("name")
+}
+
+var domainDnssecNameservers []string
+var domainDnssecTimeout time.Duration
+
+// Flags for commands that query the DNSKEY records of a domain on its nameservers.
+func addDomainDnssecQueryFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&domainDnssecNameservers, "nameserver", nil, "Nameserver to query as host[:port] instead of the nameservers of the domain. Can be given multiple times")
+	cmd.Flags().DurationVar(&domainDnssecTimeout, "timeout", 5*time.Second, "Timeout for every DNS query")
+}
+
+var domainDnssecDnskeys []string
+var domainDnssecDigests []string
+
+// Flags for commands that compute DS records from DNSKEY records.
+func addDomainDnssecDnskeyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&domainDnssecDnskeys, "dnskey", nil, "DNSKEY record data to compute DS records from, e.g. '257 3 13 mdsswUyr...'. Can be given multiple times")
+	cmd.Flags().StringSliceVar(&domainDnssecDigests, "digest", []string{"sha256"}, "Digest types of computed DS records (sha256, sha384)")
+}
+
+// A DNSKEY served by the nameservers of a domain.
+type domainDnssecKey struct {
+	KeyTag    uint16 `json:"keyTag"`
+	Type      string `json:"type"`
+	Flags     uint16 `json:"flags"`
+	Algorithm string `json:"algorithm"`
+	// DS record for this key, only for key signing keys.
+	Ds string `json:"ds"`
+}
+
+// A DS record of a domain.
+type domainDnssecDsRecord struct {
+	ID      l27.IntID `json:"id"`
+	Name    string    `json:"name"`
+	Content string    `json:"content"`
+	// Problems found when validating the record, empty if the record is valid.
+	Errors string `json:"errors"`
+}
+
+type domainDnssecStatus struct {
+	Domain string `json:"domain"`
+	Status string `json:"status"`
+	// Nameserver the keys were retrieved from.
+	Nameserver string                 `json:"nameserver"`
+	QueryError string                 `json:"queryError,omitempty"`
+	Keys       []domainDnssecKey      `json:"keys"`
+	DsRecords  []domainDnssecDsRecord `json:"dsRecords"`
+}
+
+// A DS record computed from a DNSKEY.
+type domainDnssecComputedDs struct {
+	Name       string `json:"name"`
+	KeyTag     uint16 `json:"keyTag"`
+	Algorithm  string `json:"algorithm"`
+	DigestType string `json:"digestType"`
+	Ds         string `json:"ds"`
+}
+
+// DNSSEC
+var domainDnssecCmd = &cobra.Command{
+	Use:   "dnssec",
+	Short: "Commands to manage DNSSEC on domains",
+	Long: `Commands to manage DNSSEC on domains.
+DNSKEY records are retrieved from the nameservers of the domain, DS records are computed and checked locally.`,
+}
+
+// DNSSEC STATUS
+var domainDnssecStatusCmd = &cobra.Command{
+	Use:   "status <domain>",
+	Short: "Show the DNSSEC state, key set and DS records of a domain",
+	Example: `lvl domain dnssec status example.com
+lvl domain dnssec status example.com --nameserver 127.0.0.1:5353`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domainID, err := resolveDomain(args[0])
+		if err != nil {
+			return err
+		}
+
+		domain, err := Level27Client.Domain(domainID)
+		if err != nil {
+			return err
+		}
+
+		dsRecords, err := getDomainDsRecords(domainID)
+		if err != nil {
+			return err
+		}
+
+		status := domainDnssecStatus{
+			Domain:    domain.Fullname,
+			Status:    domain.DnssecStatus,
+			Keys:      []domainDnssecKey{},
+			DsRecords: dsRecords,
+		}
+
+		origin := fmt.Sprintf("%s.", strings.ToLower(domain.Fullname))
+		keys, nameserver, err := domainDnssecQueryKeys(domain, origin)
+		status.Nameserver = nameserver
+		if err != nil {
+			status.QueryError = err.Error()
+		}
+
+		for _, key := range keys {
+			entry := domainDnssecKey{
+				KeyTag:    key.KeyTag(),
+				Type:      "ZSK",
+				Flags:     key.Flags,
+				Algorithm: key.Algorithm.String(),
+			}
+
+			if key.IsKsk() {
+				entry.Type = "KSK"
+				ds, err := key.Ds(origin, utils.DsDigestSha256)
+				if err == nil {
+					entry.Ds = ds.String()
+				}
+			}
+
+			status.Keys = append(status.Keys, entry)
+		}
+
+		outputFormatTemplate(status, "templates/domainDnssec.tmpl")
+		return nil
+	},
+}
+
+// DNSSEC ENABLE
+var domainDnssecEnableCmd = &cobra.Command{
+	Use:   "enable <domain>",
+	Short: "Enable DNSSEC on a domain",
+	Long: `Enable DNSSEC on a domain.
+The zone is signed by Level27. For domains registered with Level27 the DS records are published automatically,
+otherwise use 'lvl domain dnssec ds compute' to get the DS records to submit to the registrar once the keys are served.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domainID, err := resolveDomain(args[0])
+		if err != nil {
+			return err
+		}
+
+		err = Level27Client.DomainUpdate(domainID, map[string]interface{}{"handleDnssec": true})
+		if err != nil {
+			return err
+		}
+
+		outputFormatTemplate(nil, "templates/entities/domain/dnssecEnable.tmpl")
+		return nil
+	},
+}
+
+// DNSSEC DISABLE
+var domainDnssecDisableCmd = &cobra.Command{
+	Use:   "disable <domain>",
+	Short: "Disable DNSSEC on a domain",
+	Long: `Disable DNSSEC on a domain.
+Remove the DS records at the registrar first: a domain with DS records but without signed zone does not resolve.`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domainID, err := resolveDomain(args[0])
+		if err != nil {
+			return err
+		}
+
+		if !optDeleteConfirmed {
+			domain, err := Level27Client.Domain(domainID)
+			if err != nil {
+				return err
+			}
+
+			if !confirmPrompt(fmt.Sprintf("Disable DNSSEC on domain %s (%d)? Make sure no DS records are published for it.", domain.Fullname, domain.ID)) {
+				return nil
+			}
+		}
+
+		err = Level27Client.DomainUpdate(domainID, map[string]interface{}{"handleDnssec": false})
+		if err != nil {
+			return err
+		}
+
+		outputFormatTemplate(nil, "templates/entities/domain/dnssecDisable.tmpl")
+		return nil
+	},
+}
+
+// DNSSEC DS
+var domainDnssecDsCmd = &cobra.Command{
+	Use:   "ds",
+	Short: "Commands to manage DS records",
+}
+
+// DNSSEC DS GET
+var domainDnssecDsGetCmd = &cobra.Command{
+	Use:   "get <domain>",
+	Short: "List and validate the DS records of a domain",
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domainID, err := resolveDomain(args[0])
+		if err != nil {
+			return err
+		}
+
+		records, err := getDomainDsRecords(domainID)
+		if err != nil {
+			return err
+		}
+
+		outputFormatTable(
+			records,
+			[]string{"ID", "NAME", "CONTENT", "ERRORS"},
+			[]string{"ID", "Name", "Content", "Errors"})
+
+		return nil
+	},
+}
+
+// DNSSEC DS COMPUTE
+var domainDnssecDsComputeAll bool
+var domainDnssecDsComputeCmd = &cobra.Command{
+	Use:   "compute <domain>",
+	Short: "Compute DS records from the DNSKEY records of a domain",
+	Long: `Compute DS records from the DNSKEY records of a domain.
+The DNSKEY records are retrieved from the nameservers of the domain, unless they are given with --dnskey.
+With --dnskey, the domain does not have to exist in Level27.`,
+	Example: `lvl domain dnssec ds compute example.com --digest sha256,sha384
+lvl domain dnssec ds compute sub.example.com --dnskey '257 3 13 mdsswUyr3DPW132mOi8V9xESWE8jTo0dxCjjnopKl+GqJxpVXckHAeF+KkxLbxILfDLUT0rAK9iUzy1L53eKGQ=='`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		digestTypes, err := parseDomainDnssecDigests()
+		if err != nil {
+			return err
+		}
+
+		var owner string
+		var keys []utils.Dnskey
+		if len(domainDnssecDnskeys) != 0 {
+			owner = fmt.Sprintf("%s.", strings.ToLower(strings.TrimSuffix(args[0], ".")))
+			keys, err = parseDomainDnssecDnskeys()
+			if err != nil {
+				return err
+			}
+		} else {
+			domainID, err := resolveDomain(args[0])
+			if err != nil {
+				return err
+			}
+
+			domain, err := Level27Client.Domain(domainID)
+			if err != nil {
+				return err
+			}
+
+			owner = fmt.Sprintf("%s.", strings.ToLower(domain.Fullname))
+			keys, _, err = domainDnssecQueryKeys(domain, owner)
+			if err != nil {
+				return err
+			}
+		}
+
+		results := []domainDnssecComputedDs{}
+		for _, key := range keys {
+			if !key.IsKsk() && !domainDnssecDsComputeAll {
+				continue
+			}
+
+			for _, digestType := range digestTypes {
+				ds, err := key.Ds(owner, digestType)
+				if err != nil {
+					return err
+				}
+
+				results = append(results, domainDnssecComputedDs{
+					Name:       owner,
+					KeyTag:     ds.KeyTag,
+					Algorithm:  ds.Algorithm.String(),
+					DigestType: ds.DigestType.String(),
+					Ds:         ds.String(),
+				})
+			}
+		}
+
+		if len(results) == 0 {
+			return fmt.Errorf("no key signing keys found for %s, use --all-keys to include zone signing keys", owner)
+		}
+
+		outputFormatTable(
+			results,
+			[]string{"NAME", "KEY TAG", "ALGORITHM", "DIGEST TYPE", "DS"},
+			[]string{"Name", "KeyTag", "Algorithm", "DigestType", "Ds"})
+
+		return nil
+	},
+}
+
+// DNSSEC DS CREATE
+var domainDnssecDsCreateName string
+var domainDnssecDsCreateData []string
+var domainDnssecDsCreateCmd = &cobra.Command{
+	Use:   "create <domain>",
+	Short: "Create DS records on a domain for a delegated subdomain",
+	Long: `Create DS records on a domain for a delegated subdomain, given with --name.
+DS records are given with --ds, or computed from the DNSKEY records of the subdomain with --dnskey.
+All records are validated before any are created.`,
+	Example: `lvl domain dnssec ds create example.com --name sub --ds '60485 13 2 D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A'
+lvl domain dnssec ds create example.com --name sub --dnskey '257 3 13 mdsswUyr...' --digest sha256`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(domainDnssecDsCreateData) == 0 && len(domainDnssecDnskeys) == 0 {
+			return fmt.Errorf("no DS records given, pass them with --ds or --dnskey")
+		}
+
+		// DS records of the domain itself belong in its parent zone, they are managed by the registrar.
+		name := strings.TrimSuffix(domainDnssecDsCreateName, ".")
+		if name == "" || name == "@" {
+			return fmt.Errorf("--name must be a delegated subdomain, DS records can't be created on the domain itself")
+		}
+
+		domainID, err := resolveDomain(args[0])
+		if err != nil {
+			return err
+		}
+
+		domain, err := Level27Client.Domain(domainID)
+		if err != nil {
+			return err
+		}
+
+		origin := fmt.Sprintf("%s.", strings.ToLower(domain.Fullname))
+		owner := fmt.Sprintf("%s.%s", strings.ToLower(name), origin)
+
+		records := []utils.Ds{}
+		for _, data := range domainDnssecDsCreateData {
+			ds, err := utils.ParseDs(strings.Fields(data))
+			if err != nil {
+				return err
+			}
+
+			if errs := ds.Validate(); len(errs) != 0 {
+				return fmt.Errorf("invalid DS record '%s': %s", data, strings.Join(errs, ", "))
+			}
+
+			records = append(records, ds)
+		}
+
+		if len(domainDnssecDnskeys) != 0 {
+			digestTypes, err := parseDomainDnssecDigests()
+			if err != nil {
+				return err
+			}
+
+			keys, err := parseDomainDnssecDnskeys()
+			if err != nil {
+				return err
+			}
+
+			for _, key := range keys {
+				for _, digestType := range digestTypes {
+					ds, err := key.Ds(owner, digestType)
+					if err != nil {
+						return err
+					}
+
+					records = append(records, ds)
+				}
+			}
+		}
+
+		for _, ds := range records {
+			record, err := Level27Client.DomainRecordCreate(domainID, l27.DomainRecordRequest{
+				Name:    name,
+				Type:    "DS",
+				Content: ds.String(),
+			})
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Created DS record %s for %s [ID: %d]\n", ds.String(), owner, record.ID)
+		}
+
+		return nil
+	},
+}
+
+// Get the DS records of a domain, with the result of validating them.
+func getDomainDsRecords(domainID l27.IntID) ([]domainDnssecDsRecord, error) {
+	records, err := Level27Client.DomainRecords(domainID, "DS", l27.CommonGetParams{PageableParams: l27.PageableParams{Limit: 10000}})
+	if err != nil {
+		return nil, err
+	}
+
+	results := []domainDnssecDsRecord{}
+	for _, record := range records {
+		result := domainDnssecDsRecord{ID: record.ID, Name: record.Name, Content: record.Content}
+
+		ds, err := utils.ParseDs(strings.Fields(record.Content))
+		if err != nil {
+			result.Errors = err.Error()
+		} else {
+			result.Errors = strings.Join(ds.Validate(), ", ")
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// Query the DNSKEY records of a domain, from the first nameserver that answers.
+// Nameservers given with --nameserver are used instead of those of the domain.
+// Returns the keys and the nameserver that answered.
+func domainDnssecQueryKeys(domain l27.Domain, origin string) ([]utils.Dnskey, string, error) {
+	nameservers := domainDnssecNameservers
+	if len(nameservers) == 0 {
+		nameservers = domainNameservers(domain)
+	}
+
+	if len(nameservers) == 0 {
+		return nil, "", fmt.Errorf("domain has no nameservers, pass one with --nameserver")
+	}
+
+	var lastErr error
+	for _, nameserver := range nameservers {
+		response, err := utils.DnsQuery(dnsServerAddress(nameserver), origin, utils.RecordTypeDNSKEY, domainDnssecTimeout)
+		if err == nil && (!response.Authoritative || response.Rcode != utils.DnsRcodeNoError) {
+			err = fmt.Errorf("no authoritative answer (%v)", response.Rcode)
+		}
+
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %s", nameserver, err.Error())
+			continue
+		}
+
+		keys := []utils.Dnskey{}
+		for _, answer := range response.Answers {
+			if answer.Name != origin || answer.Type != utils.RecordTypeDNSKEY {
+				continue
+			}
+
+			key, err := utils.ParseDnskey(answer.Data)
+			if err != nil {
+				return nil, nameserver, err
+			}
+
+			// Only zone keys are used for DNSSEC, others are not referred to by DS records.
+			if key.Flags&utils.DnskeyFlagZone != 0 {
+				keys = append(keys, key)
+			}
+		}
+
+		if len(keys) == 0 {
+			return keys, nameserver, fmt.Errorf("%s does not serve DNSKEY records for %s", nameserver, origin)
+		}
+
+		return keys, nameserver, nil
+	}
+
+	return nil, "", lastErr
+}
+
+func parseDomainDnssecDnskeys() ([]utils.Dnskey, error) {
+	keys := []utils.Dnskey{}
+	for _, data := range domainDnssecDnskeys {
+		key, err := utils.ParseDnskey(strings.Fields(data))
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func parseDomainDnssecDigests() ([]utils.DsDigestType, error) {
+	digestTypes := []utils.DsDigestType{}
+	for _, digest := range domainDnssecDigests {
+		digestType, err := utils.ParseDsDigestType(digest)
+		if err != nil {
+			return nil, err
+		}
+
+		// SHA-1 DS records must not be created anymore (RFC 8624).
+		if digestType != utils.DsDigestSha256 && digestType != utils.DsDigestSha384 {
+			return nil, fmt.Errorf("unsupported digest type: '%s', use sha256 or sha384", digest)
+		}
+
+		digestTypes = append(digestTypes, digestType)
+	}
+
+	return digestTypes, nil
+}
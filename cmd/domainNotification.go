@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// NOTIFICATIONS
+	domainCmd.AddCommand(domainNotificationCmd)
+
+	// GET NOTIFICATIONS
+	domainNotificationCmd.AddCommand(domainNotificationGetCmd)
+	addCommonGetFlags(domainNotificationGetCmd)
+	domainNotificationGetCmd.Flags().StringVar(&domainNotificationGetOrderBy, "orderby", "", fmt.Sprintf("Field to order the results on, prefix with - to reverse (%s)", strings.Join(domainNotificationOrderFields, ", ")))
+
+	// CREATE NOTIFICATION
+	domainNotificationCmd.AddCommand(domainNotificationCreateCmd)
+	flags := domainNotificationCreateCmd.Flags()
+	flags.StringVarP(&domainNotificationCreateType, "type", "t", "", fmt.Sprintf("The notification type (e.g. %s)", strings.Join(domainNotificationTypes, ", ")))
+	flags.StringVarP(&domainNotificationCreateGroup, "group", "g", "", fmt.Sprintf("The notification group (e.g. %s)", strings.Join(domainNotificationGroups, ", ")))
+	flags.StringVarP(&domainNotificationCreateParams, "params", "p", "", "Additional parameters, as a JSON object")
+	flags.SortFlags = false
+	domainNotificationCreateCmd.MarkFlagRequired("type")
+	domainNotificationCreateCmd.MarkFlagRequired("group")
+
+	domainNotificationCreateCmd.RegisterFlagCompletionFunc("type", fixedCompletions(domainNotificationTypes))
+	domainNotificationCreateCmd.RegisterFlagCompletionFunc("group", fixedCompletions(domainNotificationGroups))
+}
+
+// Completion function suggesting a fixed set of values for a flag.
+func fixedCompletions(values []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// Common notification types, for completion and help. Other values are passed to the API as-is.
+var domainNotificationTypes = []string{
+	"authcode",
+	"transferRequest",
+	"transferApproved",
+	"transferRejected",
+	"expiryReminder",
+	"expired",
+	"renewed",
+}
+
+// Common groups a notification is sent to, for completion and help. Other values are passed to the API as-is.
+var domainNotificationGroups = []string{
+	"licensee",
+	"onsite",
+	"organisation",
+}
+
+// Fields notifications can be ordered on with --orderby.
+var domainNotificationOrderFields = []string{"id", "type", "group", "status", "date"}
+
+// NOTIFICATIONS
+var domainNotificationCmd = &cobra.Command{
+	Use:     "notification",
+	Aliases: []string{"notifications"},
+	Short:   "Manage domain notifications",
+	Long: `Manage domain notifications.
+Notifications are messages from the registrar about a domain, such as transfer requests and expiry reminders.`,
+}
+
+// GET NOTIFICATIONS
+var domainNotificationGetOrderBy string
+var domainNotificationGetCmd = &cobra.Command{
+	Use:   "get <domain>",
+	Short: "Get a list of all notifications of a domain",
+	Example: `lvl domain notification get example.com
+lvl domain notification get example.com --orderby -date -n 10`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		descending := strings.HasPrefix(domainNotificationGetOrderBy, "-")
+		orderBy := strings.TrimPrefix(domainNotificationGetOrderBy, "-")
+		if orderBy != "" && !sliceContains(domainNotificationOrderFields, orderBy) {
+			return fmt.Errorf("invalid --orderby: '%s'. Must be one of %s", orderBy, strings.Join(domainNotificationOrderFields, ", "))
+		}
+
+		domainID, err := resolveDomain(args[0])
+		if err != nil {
+			return err
+		}
+
+		var notifications []l27.DomainNotification
+		if orderBy == "" {
+			notifications, err = Level27Client.DomainNotificationGetList(domainID, optGetParameters)
+		} else {
+			// Ordering happens here, so all notifications are needed to apply --number to the ordered list.
+			notifications, err = getAllPages(optGetParameters.Filter, func(params l27.CommonGetParams) ([]l27.DomainNotification, error) {
+				return Level27Client.DomainNotificationGetList(domainID, params)
+			})
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if orderBy != "" {
+			sortDomainNotifications(notifications, orderBy, descending)
+			if optGetParameters.Limit > 0 && int(optGetParameters.Limit) < len(notifications) {
+				notifications = notifications[:optGetParameters.Limit]
+			}
+		}
+
+		outputFormatTableFuncs(
+			notifications,
+			[]string{"ID", "TYPE", "GROUP", "STATUS", "DATE", "PARAMS"},
+			[]interface{}{
+				"ID",
+				"Type",
+				"Group",
+				"Status",
+				func(n l27.DomainNotification) string { return utils.FormatUnixTime(n.DtStamp) },
+				"Params",
+			})
+
+		return nil
+	},
+}
+
+// Order notifications on one of domainNotificationOrderFields.
+func sortDomainNotifications(notifications []l27.DomainNotification, orderBy string, descending bool) {
+	key := func(n l27.DomainNotification) string {
+		switch orderBy {
+		case "type":
+			return n.Type
+		case "group":
+			return n.Group
+		case "status":
+			return n.Status
+		}
+
+		return ""
+	}
+
+	less := func(a, b l27.DomainNotification) bool {
+		switch orderBy {
+		case "id":
+			return a.ID < b.ID
+		case "date":
+			aTime, _ := utils.ParseUnixTime(a.DtStamp)
+			bTime, _ := utils.ParseUnixTime(b.DtStamp)
+			return aTime.Before(bTime)
+		}
+
+		return key(a) < key(b)
+	}
+
+	sort.SliceStable(notifications, func(i, j int) bool {
+		if descending {
+			return less(notifications[j], notifications[i])
+		}
+
+		return less(notifications[i], notifications[j])
+	})
+}
+
+// CREATE NOTIFICATION
+var domainNotificationCreateType, domainNotificationCreateGroup, domainNotificationCreateParams string
+
+var domainNotificationCreateCmd = &cobra.Command{
+	Use:   "create <domain>",
+	Short: "Send a notification for a domain",
+	Example: `lvl domain notification create example.com --type authcode --group licensee
+lvl domain notification create example.com -t expiryReminder -g organisation -p '{"days": 30}'`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The lists aren't exhaustive, so the API gets the final say.
+		if !sliceContains(domainNotificationTypes, domainNotificationCreateType) {
+			fmt.Fprintf(os.Stderr, "Warning: unknown notification type '%s', known types are %s\n", domainNotificationCreateType, strings.Join(domainNotificationTypes, ", "))
+		}
+
+		if !sliceContains(domainNotificationGroups, domainNotificationCreateGroup) {
+			fmt.Fprintf(os.Stderr, "Warning: unknown notification group '%s', known groups are %s\n", domainNotificationCreateGroup, strings.Join(domainNotificationGroups, ", "))
+		}
+
+		if domainNotificationCreateParams != "" {
+			var params map[string]interface{}
+			if err := json.Unmarshal([]byte(domainNotificationCreateParams), &params); err != nil {
+				return fmt.Errorf("--params must be a JSON object: %s", err.Error())
+			}
+		}
+
+		domainID, err := resolveDomain(args[0])
+		if err != nil {
+			return err
+		}
+
+		notification, err := Level27Client.DomainNotificationCreate(domainID, l27.DomainNotificationPostRequest{
+			Type:   domainNotificationCreateType,
+			Group:  domainNotificationCreateGroup,
+			Params: domainNotificationCreateParams,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		outputFormatTemplate(notification, "templates/entities/domainNotification/create.tmpl")
+		return nil
+	},
+}
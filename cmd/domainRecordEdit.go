@@ -0,0 +1,381 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	domainRecordCmd.AddCommand(domainRecordEditCmd)
+	domainRecordEditCmd.Flags().BoolVarP(&domainRecordEditYes, "yes", "y", false, "Apply changes without confirmation prompt")
+}
+
+// Prefix of the comment lines used to report errors at the top of the edited file.
+const domainRecordEditErrorPrefix = "; ERROR: "
+
+var domainRecordEditYes bool
+var domainRecordEditCmd = &cobra.Command{
+	Use:   "edit <domain>",
+	Short: "Edit the records of a domain in a text editor",
+	Long: `Edit the records of a domain in a text editor.
+The records are opened as a zone file in $VISUAL or $EDITOR. After saving and closing the editor,
+the file is validated and re-opened if there are errors. Only the changed records are then updated in the API.
+Records that cannot be represented in a zone file are left untouched.`,
+	Example: `lvl domain record edit example.com
+EDITOR="code --wait" lvl domain record edit example.com`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domainID, err := resolveDomain(args[0])
+		if err != nil {
+			return err
+		}
+
+		domain, err := Level27Client.Domain(domainID)
+		if err != nil {
+			return err
+		}
+
+		records, err := Level27Client.DomainRecords(domainID, "", l27.CommonGetParams{PageableParams: l27.PageableParams{Limit: 10000}})
+		if err != nil {
+			return err
+		}
+
+		origin := fmt.Sprintf("%s.", strings.ToLower(domain.Fullname))
+		editable := domainRecordEditNormalize(origin, records)
+
+		var initial bytes.Buffer
+		domainRecordEditWrite(&initial, origin, editable)
+
+		edited, changed, err := domainRecordEditLoop(initial.Bytes(), origin)
+		if err != nil {
+			return err
+		}
+
+		if !changed {
+			fmt.Println("No changes made")
+			return nil
+		}
+
+		deletes, updates, creates := domainRecordEditDiff(editable, edited)
+		if len(deletes)+len(updates)+len(creates) == 0 {
+			fmt.Println("No changes made")
+			return nil
+		}
+
+		for _, record := range deletes {
+			fmt.Printf("- %s\n", domainRecordEditDescribe(record.Item2))
+		}
+
+		for _, update := range updates {
+			fmt.Printf("~ %s -> %s\n", domainRecordEditDescribe(update.Item1.Item2), domainRecordEditDescribe(update.Item2))
+		}
+
+		for _, request := range creates {
+			fmt.Printf("+ %s\n", domainRecordEditDescribe(request))
+		}
+
+		if !domainRecordEditYes {
+			if !confirmPrompt(fmt.Sprintf("Delete %d, update %d and create %d records?", len(deletes), len(updates), len(creates))) {
+				return nil
+			}
+		}
+
+		// Deletes go first so replaced records (e.g. a CNAME) don't conflict with new ones.
+		for _, record := range deletes {
+			err = Level27Client.DomainRecordDelete(domainID, record.Item1)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, update := range updates {
+			err = Level27Client.DomainRecordUpdate(domainID, update.Item1.Item1, update.Item2)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, request := range creates {
+			_, err = Level27Client.DomainRecordCreate(domainID, request)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Println("All changes successfully applied")
+
+		return nil
+	},
+}
+
+// Convert records from the API to the request they would produce after being written to and read from a zone file.
+// Records that do not survive this round trip are skipped, so they will never be touched by the edit.
+func domainRecordEditNormalize(origin string, records []l27.DomainRecord) []tuple2[l27.IntID, l27.DomainRecordRequest] {
+	editable := []tuple2[l27.IntID, l27.DomainRecordRequest]{}
+	for _, record := range records {
+		line := fmt.Sprintf(
+			"$ORIGIN %s\n%s IN %s %s\n",
+			origin,
+			domainRecordEditZoneName(record.Name),
+			record.Type,
+			domainRecordEditZoneData(record.Type, record.Priority, record.Content))
+
+		requests, issues := domainRecordEditParse([]byte(line), origin)
+		if len(issues) != 0 || len(requests) != 1 {
+			fmt.Printf("Note: record %d (%s %s) cannot be edited and will be left unchanged.\n", record.ID, record.Type, record.Name)
+			continue
+		}
+
+		editable = append(editable, makeTuple2(record.ID, requests[0]))
+	}
+
+	return editable
+}
+
+// Write the editable records as a zone file.
+func domainRecordEditWrite(w io.Writer, origin string, records []tuple2[l27.IntID, l27.DomainRecordRequest]) {
+	fmt.Fprintf(w, "; Records of %s\n", origin)
+	fmt.Fprintln(w, "; Records removed from this file will be deleted, TTL values are not supported.")
+	fmt.Fprintln(w, "; Empty the file completely to abort.")
+	fmt.Fprintf(w, "$ORIGIN %s\n\n", origin)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	for _, record := range records {
+		request := record.Item2
+		fmt.Fprintf(
+			tw,
+			"%s\tIN\t%s\t%s\n",
+			domainRecordEditZoneName(request.Name),
+			request.Type,
+			domainRecordEditZoneData(request.Type, request.Priority, request.Content))
+	}
+
+	tw.Flush()
+}
+
+func domainRecordEditZoneName(name string) string {
+	if name == "" {
+		return "@"
+	}
+
+	return name
+}
+
+// Get the zone file representation of the content of a record.
+func domainRecordEditZoneData(recordType string, priority int32, content string) string {
+	switch recordType {
+	case "MX":
+		return fmt.Sprintf("%d %s", priority, content)
+	case "TXT":
+		// Split long TXT records into multiple strings, these are joined back together on import.
+		parts := []string{}
+		for len(content) > 255 {
+			parts = append(parts, utils.ZoneQuoteString(content[:255]))
+			content = content[255:]
+		}
+
+		return strings.Join(append(parts, utils.ZoneQuoteString(content)), " ")
+	default:
+		return content
+	}
+}
+
+// Parse an edited zone file into record requests.
+// Returns the issues that prevent the file from being applied.
+func domainRecordEditParse(text []byte, origin string) ([]l27.DomainRecordRequest, []utils.ZoneLintIssue) {
+	contents := utils.ReadZone(bytes.NewReader(text), origin)
+
+	issues := []utils.ZoneLintIssue{}
+	for _, parseErr := range contents.Errors {
+		issues = append(issues, utils.ZoneLintIssue{Line: parseErr.Line, Message: parseErr.Err.Error()})
+	}
+
+	for _, issue := range utils.LintZoneRecords(origin, contents.Records) {
+		if issue.Severity == utils.ZoneLintError {
+			issues = append(issues, issue)
+		}
+	}
+
+	requests := []l27.DomainRecordRequest{}
+	for _, record := range contents.Records {
+		if record.Class != utils.DnsClassIN {
+			issues = append(issues, utils.ZoneLintIssue{Line: record.Line, Message: "only IN records are supported"})
+			continue
+		}
+
+		if record.Ttl != nil {
+			issues = append(issues, utils.ZoneLintIssue{Line: record.Line, Message: "per-record TTL values are not supported"})
+			continue
+		}
+
+		if !utils.ZoneDomainInZone(record.Name, origin) {
+			// Already reported by lint.
+			continue
+		}
+
+		name := utils.ZoneDomainRelative(record.Name, origin)
+		if name == "@" {
+			name = ""
+		}
+
+		request, err := zoneRecordRequest(record.Type, name, record.Data)
+		if err != nil {
+			issues = append(issues, utils.ZoneLintIssue{Line: record.Line, Message: err.Error()})
+			continue
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, issues
+}
+
+// Open the records in an editor until the user saves a valid file.
+// Returns the edited records, and whether the file was changed at all. An emptied file counts as unchanged.
+func domainRecordEditLoop(initial []byte, origin string) ([]l27.DomainRecordRequest, bool, error) {
+	file, err := os.CreateTemp("", "lvl-records-*.zone")
+	if err != nil {
+		return nil, false, err
+	}
+
+	fileName := file.Name()
+	defer os.Remove(fileName)
+
+	_, err = file.Write(initial)
+	file.Close()
+	if err != nil {
+		return nil, false, err
+	}
+
+	for {
+		err = runEditor(fileName)
+		if err != nil {
+			return nil, false, fmt.Errorf("editor failed: %s", err.Error())
+		}
+
+		text, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if bytes.Equal(text, initial) {
+			return nil, false, nil
+		}
+
+		body := domainRecordEditStripErrors(text)
+		// Emptying the file is how to abort the edit.
+		if len(bytes.TrimSpace(body)) == 0 {
+			return nil, false, nil
+		}
+
+		requests, issues := domainRecordEditParse(body, origin)
+		if len(issues) == 0 {
+			return requests, true, nil
+		}
+
+		fmt.Printf("Found %d error(s), re-opening editor\n", len(issues))
+
+		// Errors are put at the top of the file, so line numbers shift by the size of that header.
+		var buf bytes.Buffer
+		for _, issue := range issues {
+			fmt.Fprintf(&buf, "%sline %d: %s\n", domainRecordEditErrorPrefix, issue.Line+len(issues), issue.Message)
+		}
+
+		buf.Write(body)
+
+		err = os.WriteFile(fileName, buf.Bytes(), 0o600)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// Remove the error comments added by a previous round of editing.
+func domainRecordEditStripErrors(text []byte) []byte {
+	for bytes.HasPrefix(text, []byte(domainRecordEditErrorPrefix)) {
+		idx := bytes.IndexByte(text, '\n')
+		if idx == -1 {
+			return nil
+		}
+
+		text = text[idx+1:]
+	}
+
+	return text
+}
+
+// Compute the changes needed to get from the original records to the edited ones.
+// Records that are unchanged are matched up first, removed and added records with the same name and type become updates.
+func domainRecordEditDiff(
+	original []tuple2[l27.IntID, l27.DomainRecordRequest],
+	edited []l27.DomainRecordRequest,
+) (
+	deletes []tuple2[l27.IntID, l27.DomainRecordRequest],
+	updates []tuple2[tuple2[l27.IntID, l27.DomainRecordRequest], l27.DomainRecordRequest],
+	creates []l27.DomainRecordRequest,
+) {
+	used := make([]bool, len(original))
+	added := []l27.DomainRecordRequest{}
+
+	for _, request := range edited {
+		idx := -1
+		for i, record := range original {
+			if !used[i] && domainRecordRequestEqual(record.Item2, request) {
+				idx = i
+				break
+			}
+		}
+
+		if idx == -1 {
+			added = append(added, request)
+		} else {
+			used[idx] = true
+		}
+	}
+
+	for _, request := range added {
+		idx := -1
+		for i, record := range original {
+			if !used[i] && record.Item2.Type == request.Type && record.Item2.Name == request.Name {
+				idx = i
+				break
+			}
+		}
+
+		if idx == -1 {
+			creates = append(creates, request)
+		} else {
+			used[idx] = true
+			updates = append(updates, makeTuple2(original[idx], request))
+		}
+	}
+
+	for i, record := range original {
+		if !used[i] {
+			deletes = append(deletes, record)
+		}
+	}
+
+	return deletes, updates, creates
+}
+
+func domainRecordRequestEqual(a l27.DomainRecordRequest, b l27.DomainRecordRequest) bool {
+	return a.Type == b.Type && a.Name == b.Name && a.Content == b.Content && a.Priority == b.Priority
+}
+
+func domainRecordEditDescribe(request l27.DomainRecordRequest) string {
+	return fmt.Sprintf(
+		"%s %s %s",
+		domainRecordEditZoneName(request.Name),
+		request.Type,
+		domainRecordEditZoneData(request.Type, request.Priority, request.Content))
+}
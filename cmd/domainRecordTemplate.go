@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+)
+
+// Record templates are zone file snippets, rendered as Go templates with the -p parameters.
+// Built-in templates are embedded in templates/dnsrecords,
+// user templates are read from the dnstemplates directory next to the config file.
+
+const domainRecordTemplateExt = ".zone"
+const domainRecordTemplateBuiltinDir = "templates/dnsrecords"
+
+func init() {
+	domainRecordCmd.AddCommand(domainRecordTemplateCmd)
+
+	// LIST
+	domainRecordTemplateCmd.AddCommand(domainRecordTemplateListCmd)
+
+	// APPLY
+	domainRecordTemplateCmd.AddCommand(domainRecordTemplateApplyCmd)
+	flags := domainRecordTemplateApplyCmd.Flags()
+	flags.StringArrayVarP(&domainRecordTemplateApplyParams, "param", "p", nil, "Template parameter, as key=value")
+	flags.BoolVarP(&domainRecordTemplateApplyYes, "yes", "y", false, "Apply the template without confirmation prompt")
+	flags.BoolVar(&domainRecordTemplateApplyDryRun, "dry-run", false, "Only show the records that would be created")
+}
+
+var domainRecordTemplateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Apply reusable sets of records to domains",
+	Long: `Apply reusable sets of records to domains.
+Templates are zone file snippets that can use Go template syntax (including sprig functions) for parameters.
+Parameters are available as {{ .name }}, the domain name (without trailing dot) as {{ .Domain }}.
+User templates are read from the ".lvl/dnstemplates" directory next to the config file (e.g. ~/.lvl/dnstemplates/mytemplate.zone)
+and take precedence over built-in templates with the same name.`,
+}
+
+type domainRecordTemplateInfo struct {
+	Name        string `json:"name"`
+	Source      string `json:"source"`
+	Description string `json:"description"`
+}
+
+// DOMAIN RECORD TEMPLATE LIST
+var domainRecordTemplateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available record templates",
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		infos := map[string]domainRecordTemplateInfo{}
+
+		builtins, err := fs.ReadDir(templates, domainRecordTemplateBuiltinDir)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range builtins {
+			text, err := templates.ReadFile(path.Join(domainRecordTemplateBuiltinDir, entry.Name()))
+			if err != nil {
+				return err
+			}
+
+			name := strings.TrimSuffix(entry.Name(), domainRecordTemplateExt)
+			infos[name] = domainRecordTemplateInfo{name, "built-in", domainRecordTemplateDescription(string(text))}
+		}
+
+		userDir := domainRecordTemplateUserDir()
+		userFiles, err := filepath.Glob(filepath.Join(userDir, "*"+domainRecordTemplateExt))
+		if err != nil {
+			return err
+		}
+
+		for _, file := range userFiles {
+			text, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+
+			name := strings.TrimSuffix(filepath.Base(file), domainRecordTemplateExt)
+			infos[name] = domainRecordTemplateInfo{name, "user", domainRecordTemplateDescription(string(text))}
+		}
+
+		list := []domainRecordTemplateInfo{}
+		for _, info := range infos {
+			list = append(list, info)
+		}
+
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+		outputFormatTable(list, []string{"NAME", "SOURCE", "DESCRIPTION"}, []string{"Name", "Source", "Description"})
+		return nil
+	},
+}
+
+// A record produced by a template, with what applying it will do.
+type domainRecordTemplateRecord struct {
+	Action   string `json:"action"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Priority int32  `json:"priority"`
+	Content  string `json:"content"`
+}
+
+// DOMAIN RECORD TEMPLATE APPLY
+var domainRecordTemplateApplyParams []string
+var domainRecordTemplateApplyYes bool
+var domainRecordTemplateApplyDryRun bool
+var domainRecordTemplateApplyCmd = &cobra.Command{
+	Use:   "apply <domain> <template>",
+	Short: "Create the records of a template on a domain",
+	Long: `Create the records of a template on a domain.
+The template is rendered and checked against the existing records of the domain first.
+Records that already exist are skipped. If a record would conflict with existing records
+(e.g. a CNAME next to other records or a second SPF record), nothing is applied.
+The template may be the name of a built-in or user template, or a path to a template file.`,
+	Example: `lvl domain record template apply example.com m365
+lvl domain record template apply example.com cdn -p target=example.cdn.net -p names=www,static
+lvl domain record template apply example.com ./mytemplate.zone --dry-run`,
+
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		params := map[string]string{}
+		for _, param := range domainRecordTemplateApplyParams {
+			split := strings.SplitN(param, "=", 2)
+			if len(split) != 2 {
+				return fmt.Errorf("expected key=value pair to --param: %s", param)
+			}
+
+			params[split[0]] = split[1]
+		}
+
+		templateText, err := domainRecordTemplateLoad(args[1])
+		if err != nil {
+			return err
+		}
+
+		domainID, err := resolveDomain(args[0])
+		if err != nil {
+			return err
+		}
+
+		domain, err := Level27Client.Domain(domainID)
+		if err != nil {
+			return err
+		}
+
+		existingRecords, err := Level27Client.DomainRecords(domainID, "", l27.CommonGetParams{PageableParams: l27.PageableParams{Limit: 10000}})
+		if err != nil {
+			return err
+		}
+
+		domainName := strings.ToLower(domain.Fullname)
+		origin := domainName + "."
+
+		if _, ok := params["Domain"]; !ok {
+			params["Domain"] = domainName
+		}
+
+		rendered, err := domainRecordTemplateRender(args[1], templateText, params)
+		if err != nil {
+			return err
+		}
+
+		requests, issues := domainRecordEditParse(rendered, origin)
+		if len(issues) != 0 {
+			for _, issue := range issues {
+				fmt.Printf("line %d: %s\n", issue.Line, issue.Message)
+			}
+
+			return fmt.Errorf("template '%s' produced invalid records", args[1])
+		}
+
+		existing := domainRecordEditNormalize(origin, existingRecords)
+
+		preview := []domainRecordTemplateRecord{}
+		toCreate := []l27.DomainRecordRequest{}
+		for _, request := range requests {
+			action := "create"
+			for _, record := range existing {
+				if domainRecordTemplateEqual(record.Item2, request) {
+					action = "exists"
+					break
+				}
+			}
+
+			if action == "create" {
+				toCreate = append(toCreate, request)
+			}
+
+			preview = append(preview, domainRecordTemplateRecord{
+				Action:   action,
+				Name:     domainRecordEditZoneName(request.Name),
+				Type:     request.Type,
+				Priority: request.Priority,
+				Content:  request.Content,
+			})
+		}
+
+		conflicts := domainRecordTemplateConflicts(origin, existing, toCreate)
+
+		outputFormatTable(
+			preview,
+			[]string{"ACTION", "NAME", "TYPE", "PRIORITY", "CONTENT"},
+			[]string{"Action", "Name", "Type", "Priority", "Content"})
+
+		if len(conflicts) != 0 {
+			for _, conflict := range conflicts {
+				fmt.Printf("Conflict: %s %s: %s\n", domainRecordEditZoneName(utils.ZoneDomainRelative(conflict.Name, origin)), conflict.Type, conflict.Message)
+			}
+
+			return fmt.Errorf("template '%s' conflicts with %d existing record(s)", args[1], len(conflicts))
+		}
+
+		if len(toCreate) == 0 {
+			fmt.Println("All records of the template already exist")
+			return nil
+		}
+
+		if domainRecordTemplateApplyDryRun {
+			return nil
+		}
+
+		if !domainRecordTemplateApplyYes {
+			if !confirmPrompt(fmt.Sprintf("Create %d records?", len(toCreate))) {
+				return nil
+			}
+		}
+
+		for _, request := range toCreate {
+			_, err := Level27Client.DomainRecordCreate(domainID, request)
+			if err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("Created %d records\n", len(toCreate))
+
+		return nil
+	},
+}
+
+// Directory containing user record templates.
+func domainRecordTemplateUserDir() string {
+	return filepath.Join(lvlDataDir(), "dnstemplates")
+}
+
+// Load the text of a record template, by path, user template name or built-in template name.
+func domainRecordTemplateLoad(name string) (string, error) {
+	if strings.ContainsAny(name, `/\`) || strings.HasSuffix(name, domainRecordTemplateExt) {
+		text, err := os.ReadFile(name)
+		return string(text), err
+	}
+
+	text, err := os.ReadFile(filepath.Join(domainRecordTemplateUserDir(), name+domainRecordTemplateExt))
+	if err == nil {
+		return string(text), nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	text, err = templates.ReadFile(path.Join(domainRecordTemplateBuiltinDir, name+domainRecordTemplateExt))
+	if err != nil {
+		return "", fmt.Errorf("unknown record template: '%s'", name)
+	}
+
+	return string(text), nil
+}
+
+// The description of a template is its first line, if that is a comment.
+func domainRecordTemplateDescription(text string) string {
+	firstLine := strings.SplitN(text, "\n", 2)[0]
+	if !strings.HasPrefix(firstLine, ";") {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(firstLine, ";"))
+}
+
+func domainRecordTemplateRender(name string, text string, params map[string]string) ([]byte, error) {
+	tmpl := template.New(name)
+	tmpl.Funcs(sprig.TxtFuncMap())
+	tmpl.Funcs(utils.MakeTemplateHelpers(tmpl))
+	// Missing parameters render as empty strings, so templates can use "default" and "fail".
+	tmpl.Option("missingkey=zero")
+
+	_, err := tmpl.Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %s", err.Error())
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template: %s", err.Error())
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Check whether a record from a template is the same as an existing record.
+// Templates use absolute targets with a trailing dot, the API stores them without,
+// so domain names in the content are compared without trailing dot and case.
+func domainRecordTemplateEqual(existing l27.DomainRecordRequest, request l27.DomainRecordRequest) bool {
+	return existing.Type == request.Type &&
+		existing.Name == request.Name &&
+		existing.Priority == request.Priority &&
+		domainRecordContentKey(existing.Type, existing.Content) == domainRecordContentKey(request.Type, request.Content)
+}
+
+// Get a normalized form of the content of a record, for comparison.
+func domainRecordContentKey(recordType string, content string) string {
+	switch recordType {
+	case "CNAME", "NS", "MX", "SRV":
+		// The domain name is the last item of the content for all of these.
+		return strings.TrimSuffix(strings.ToLower(content), ".")
+	default:
+		return content
+	}
+}
+
+// Find records to create that conflict with the existing records of a domain.
+// This lints the existing records with and without the new records,
+// any errors that only show up with the new records are conflicts.
+func domainRecordTemplateConflicts(
+	origin string,
+	existing []tuple2[l27.IntID, l27.DomainRecordRequest],
+	toCreate []l27.DomainRecordRequest,
+) []utils.ZoneLintIssue {
+	var buf bytes.Buffer
+	domainRecordEditWrite(&buf, origin, existing)
+
+	existingIssues := map[utils.ZoneLintIssue]bool{}
+	for _, issue := range utils.LintZoneRecords(origin, utils.ReadZone(&buf, origin).Records) {
+		existingIssues[issue] = true
+	}
+
+	// New records are written after the existing ones, so line numbers of existing records stay the same.
+	combined := append([]tuple2[l27.IntID, l27.DomainRecordRequest]{}, existing...)
+	for _, request := range toCreate {
+		combined = append(combined, makeTuple2(l27.IntID(0), request))
+	}
+
+	buf.Reset()
+	domainRecordEditWrite(&buf, origin, combined)
+
+	conflicts := []utils.ZoneLintIssue{}
+	for _, issue := range utils.LintZoneRecords(origin, utils.ReadZone(&buf, origin).Records) {
+		if issue.Severity == utils.ZoneLintError && !existingIssues[issue] {
+			conflicts = append(conflicts, issue)
+		}
+	}
+
+	return conflicts
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	domainRecordCmd.AddCommand(domainRecordVerifyCmd)
+	flags := domainRecordVerifyCmd.Flags()
+	flags.StringArrayVar(&domainRecordVerifyNameservers, "nameserver", nil, "Nameserver to query as host[:port] instead of the nameservers of the domain. Can be given multiple times")
+	flags.DurationVar(&domainRecordVerifyTimeout, "timeout", 5*time.Second, "Timeout for every DNS query")
+	flags.BoolVar(&domainRecordVerifyAll, "all", false, "Also show records that are served correctly")
+}
+
+// Result of checking a single record on a single nameserver.
+type domainRecordVerifyResult struct {
+	Nameserver string `json:"nameserver"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	// One of ok, missing, mismatch, extra or error.
+	Status   string `json:"status"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// Records of the same name and type, which are answered by a single DNS query.
+type domainRecordVerifyRrset struct {
+	Name    string
+	Type    utils.RecordType
+	Records []utils.ZoneRecord
+}
+
+var domainRecordVerifyNameservers []string
+var domainRecordVerifyTimeout time.Duration
+var domainRecordVerifyAll bool
+var domainRecordVerifyCmd = &cobra.Command{
+	Use:   "verify <domain>",
+	Short: "Check that the nameservers of a domain serve its records",
+	Long: `Check that the nameservers of a domain serve its records.
+Every record of the domain is queried on every nameserver of the domain (nameserver 1 to 4).
+Records that are not served are reported as missing, records served with different data as mismatch
+and additional records served for the same name and type as extra.
+The command exits with a non-zero status if any problems are found.`,
+	Example: `lvl domain record verify example.com
+lvl domain record verify example.com --nameserver 127.0.0.1:5353`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domainID, err := resolveDomain(args[0])
+		if err != nil {
+			return err
+		}
+
+		domain, err := Level27Client.Domain(domainID)
+		if err != nil {
+			return err
+		}
+
+		records, err := Level27Client.DomainRecords(domainID, "", l27.CommonGetParams{PageableParams: l27.PageableParams{Limit: 10000}})
+		if err != nil {
+			return err
+		}
+
+		nameservers := domainRecordVerifyNameservers
+		if len(nameservers) == 0 {
+			nameservers = domainNameservers(domain)
+		}
+
+		if len(nameservers) == 0 {
+			return fmt.Errorf("domain has no nameservers, pass one with --nameserver")
+		}
+
+		origin := fmt.Sprintf("%s.", strings.ToLower(domain.Fullname))
+		rrsets := domainRecordVerifyRrsets(origin, records)
+
+		// Query all nameservers in parallel.
+		tasks := []<-chan resultPair[[]domainRecordVerifyResult]{}
+		for _, nameserver := range nameservers {
+			nameserver := nameserver
+			tasks = append(tasks, taskRun(func() ([]domainRecordVerifyResult, error) {
+				return domainRecordVerifyNameserver(nameserver, rrsets), nil
+			}))
+		}
+
+		results := []domainRecordVerifyResult{}
+		problems := 0
+		for _, task := range tasks {
+			for _, result := range (<-task).Result {
+				if result.Status != "ok" {
+					problems += 1
+				} else if !domainRecordVerifyAll {
+					continue
+				}
+
+				results = append(results, result)
+			}
+		}
+
+		outputFormatTable(
+			results,
+			[]string{"NAMESERVER", "NAME", "TYPE", "STATUS", "EXPECTED", "ACTUAL"},
+			[]string{"Nameserver", "Name", "Type", "Status", "Expected", "Actual"})
+
+		if problems != 0 {
+			return fmt.Errorf("found %d problem(s) on %d nameserver(s)", problems, len(nameservers))
+		}
+
+		return nil
+	},
+}
+
+// Get the nameservers (1 to 4) of a domain.
+func domainNameservers(domain l27.Domain) []string {
+	nameservers := []string{}
+	for _, nameserver := range []string{domain.Nameserver1, domain.Nameserver2, domain.Nameserver3, domain.Nameserver4} {
+		if nameserver != "" {
+			nameservers = append(nameservers, nameserver)
+		}
+	}
+
+	return nameservers
+}
+
+// Get the address to send DNS queries to for a nameserver given as host[:port].
+func dnsServerAddress(nameserver string) string {
+	if _, _, err := net.SplitHostPort(nameserver); err == nil {
+		return nameserver
+	}
+
+	return net.JoinHostPort(strings.TrimSuffix(nameserver, "."), "53")
+}
+
+// Group the records of a domain by name and type, in the order they are first seen.
+func domainRecordVerifyRrsets(origin string, records []l27.DomainRecord) []domainRecordVerifyRrset {
+	// Go through a zone file to get the records in the same form as DNS answers.
+	// Host names in record content are treated the same way as when importing other providers' exports.
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "$ORIGIN %s\n", origin)
+	for _, record := range records {
+		zoneImportWriteRecord(&buf, origin, record.Name, record.Type, strconv.Itoa(int(record.Priority)), record.Content)
+	}
+
+	contents := utils.ReadZone(&buf, origin)
+	for _, parseErr := range contents.Errors {
+		// Every record is written on its own line, after the $ORIGIN line.
+		if idx := parseErr.Line - 2; idx >= 0 && idx < len(records) {
+			fmt.Printf("Note: record %d cannot be verified: %s\n", records[idx].ID, parseErr.Err.Error())
+		}
+	}
+
+	rrsets := []domainRecordVerifyRrset{}
+	index := map[string]int{}
+	for _, record := range contents.Records {
+		key := fmt.Sprintf("%s %v", record.Name, record.Type)
+		idx, ok := index[key]
+		if !ok {
+			idx = len(rrsets)
+			index[key] = idx
+			rrsets = append(rrsets, domainRecordVerifyRrset{Name: record.Name, Type: record.Type})
+		}
+
+		rrsets[idx].Records = append(rrsets[idx].Records, record)
+	}
+
+	return rrsets
+}
+
+// Query all records on a single nameserver.
+func domainRecordVerifyNameserver(nameserver string, rrsets []domainRecordVerifyRrset) []domainRecordVerifyResult {
+	server := dnsServerAddress(nameserver)
+	results := []domainRecordVerifyResult{}
+	for _, rrset := range rrsets {
+		makeResult := func(status string, expected string, actual string) domainRecordVerifyResult {
+			return domainRecordVerifyResult{
+				Nameserver: nameserver,
+				Name:       rrset.Name,
+				Type:       rrset.Type.String(),
+				Status:     status,
+				Expected:   expected,
+				Actual:     actual,
+			}
+		}
+
+		response, err := utils.DnsQuery(server, rrset.Name, rrset.Type, domainRecordVerifyTimeout)
+		if err == nil && !response.Authoritative {
+			err = fmt.Errorf("answer is not authoritative")
+		}
+
+		if err == nil && response.Rcode != utils.DnsRcodeNoError && response.Rcode != utils.DnsRcodeNXDomain {
+			err = fmt.Errorf("server responded with %v", response.Rcode)
+		}
+
+		if err != nil {
+			results = append(results, makeResult("error", "", err.Error()))
+			continue
+		}
+
+		// Only answers for the queried name and type count, e.g. not the target of a CNAME.
+		actual := []string{}
+		for _, answer := range response.Answers {
+			if answer.Name == rrset.Name && answer.Type == rrset.Type {
+				actual = append(actual, answer.ZoneRecord().DataKey())
+			}
+		}
+
+		missing := []string{}
+		for _, record := range rrset.Records {
+			expected := record.DataKey()
+			idx := indexOf(actual, expected)
+			if idx == -1 {
+				missing = append(missing, expected)
+				continue
+			}
+
+			actual = append(actual[:idx], actual[idx+1:]...)
+			results = append(results, makeResult("ok", expected, expected))
+		}
+
+		// Pair up missing and extra data as mismatches, the rest is plain missing or extra.
+		for len(missing) != 0 && len(actual) != 0 {
+			results = append(results, makeResult("mismatch", missing[0], actual[0]))
+			missing = missing[1:]
+			actual = actual[1:]
+		}
+
+		for _, expected := range missing {
+			results = append(results, makeResult("missing", expected, ""))
+		}
+
+		for _, extra := range actual {
+			results = append(results, makeResult("extra", "", extra))
+		}
+	}
+
+	return results
+}
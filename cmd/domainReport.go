@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	domainCmd.AddCommand(domainReportCmd)
+	domainReportCmd.Flags().StringVar(&domainReportExpiringWithin, "expiring-within", "60d", "List domains expiring within this period, in days (d), weeks (w), months of 30 days (m) or years (y), e.g. 30d, 8w, 3m")
+	domainReportCmd.Flags().StringVarP(&domainReportFilter, "filter", "f", "", "How to filter API results?")
+}
+
+// A single domain in the report.
+type domainReportDomain struct {
+	ID        l27.IntID `json:"id"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Extension string    `json:"extension"`
+	Registrar string    `json:"registrar"`
+	HandleDns bool      `json:"handleDns"`
+	// Expiry date as YYYY-MM-DD, empty if unknown.
+	Expires  string `json:"expires"`
+	DaysLeft int    `json:"daysLeft"`
+
+	expiresTime time.Time
+}
+
+type domainReportCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type domainReportSummary struct {
+	Title  string              `json:"title"`
+	Counts []domainReportCount `json:"counts"`
+}
+
+type domainReport struct {
+	Total              int                   `json:"total"`
+	ExpiringWithinDays int                   `json:"expiringWithinDays"`
+	Summary            []domainReportSummary `json:"summary"`
+	Expiring           []domainReportDomain  `json:"expiring"`
+	Domains            []domainReportDomain  `json:"domains"`
+}
+
+// Expiry buckets used in the summary, in days.
+var domainReportExpiryBuckets = []int{30, 90, 365}
+
+var domainReportExpiringWithin string
+var domainReportFilter string
+var domainReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summarize the status and expiry of all domains",
+	Long: `Summarize the status and expiry of all domains.
+Domains are counted by status, expiry, extension, registrar and whether DNS is handled by Level27,
+followed by a list of domains that expire soon.
+With -o csv, a line for every domain is written instead, e.g. for use in a spreadsheet.`,
+	Example: `lvl domain report
+lvl domain report --expiring-within 3m
+lvl domain report -o csv > domains.csv`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		expiringWithin, err := utils.ParseDays(domainReportExpiringWithin)
+		if err != nil {
+			return fmt.Errorf("invalid --expiring-within: %s", err.Error())
+		}
+
+		domains, err := getAllPages(domainReportFilter, Level27Client.Domains)
+		if err != nil {
+			return err
+		}
+
+		report := makeDomainReport(domains, time.Now(), time.Duration(expiringWithin)*24*time.Hour)
+
+		if viper.GetString("output") == "csv" {
+			outputFormatTable(
+				report.Domains,
+				[]string{"ID", "NAME", "STATUS", "EXTENSION", "REGISTRAR", "HANDLE DNS", "EXPIRES", "DAYS LEFT"},
+				[]string{"ID", "Name", "Status", "Extension", "Registrar", "HandleDns", "Expires", "DaysLeft"})
+		} else {
+			outputFormatTemplate(report, "templates/domainReport.tmpl")
+		}
+
+		return nil
+	},
+}
+
+func makeDomainReport(domains []l27.Domain, now time.Time, expiringWithin time.Duration) domainReport {
+	report := domainReport{
+		Total:              len(domains),
+		ExpiringWithinDays: int(expiringWithin.Hours() / 24),
+		Domains:            []domainReportDomain{},
+		Expiring:           []domainReportDomain{},
+	}
+
+	for _, domain := range domains {
+		entry := domainReportDomain{
+			ID:        domain.ID,
+			Name:      domain.Fullname,
+			Status:    domain.Status,
+			Extension: domain.Domaintype.Extension,
+			Registrar: domain.Provider.Name,
+			HandleDns: domain.DNSIsHandled,
+		}
+
+		if expires, ok := utils.ParseUnixTime(domain.DtExpires); ok {
+			entry.expiresTime = expires
+			entry.Expires = expires.Format("2006-01-02")
+			entry.DaysLeft = int(expires.Sub(now).Hours() / 24)
+		}
+
+		report.Domains = append(report.Domains, entry)
+
+		if entry.Expires != "" && entry.expiresTime.Before(now.Add(expiringWithin)) {
+			report.Expiring = append(report.Expiring, entry)
+		}
+	}
+
+	sort.SliceStable(report.Expiring, func(i, j int) bool {
+		return report.Expiring[i].expiresTime.Before(report.Expiring[j].expiresTime)
+	})
+
+	report.Summary = []domainReportSummary{
+		domainReportCountBy("Status", report.Domains, func(d domainReportDomain) string { return d.Status }),
+		domainReportCountBy("Expiry", report.Domains, domainReportExpiryBucket),
+		domainReportCountBy("Extension", report.Domains, func(d domainReportDomain) string { return d.Extension }),
+		domainReportCountBy("Registrar", report.Domains, func(d domainReportDomain) string { return d.Registrar }),
+		domainReportCountBy("DNS handled", report.Domains, func(d domainReportDomain) string { return fmt.Sprint(d.HandleDns) }),
+	}
+
+	return report
+}
+
+func domainReportExpiryBucket(domain domainReportDomain) string {
+	if domain.Expires == "" {
+		return "unknown"
+	}
+
+	if domain.DaysLeft < 0 {
+		return "expired"
+	}
+
+	for _, bucket := range domainReportExpiryBuckets {
+		if domain.DaysLeft <= bucket {
+			return fmt.Sprintf("within %d days", bucket)
+		}
+	}
+
+	return fmt.Sprintf("after %d days", domainReportExpiryBuckets[len(domainReportExpiryBuckets)-1])
+}
+
+// Count domains by some property. Counts are sorted from most to least common.
+func domainReportCountBy(title string, domains []domainReportDomain, key func(domainReportDomain) string) domainReportSummary {
+	counts := map[string]int{}
+	for _, domain := range domains {
+		value := key(domain)
+		if value == "" {
+			value = "unknown"
+		}
+
+		counts[value] += 1
+	}
+
+	summary := domainReportSummary{Title: title, Counts: []domainReportCount{}}
+	for value, count := range counts {
+		summary.Counts = append(summary.Counts, domainReportCount{Value: value, Count: count})
+	}
+
+	sort.Slice(summary.Counts, func(i, j int) bool {
+		a, b := summary.Counts[i], summary.Counts[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+
+		return a.Value < b.Value
+	})
+
+	return summary
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
@@ -14,15 +16,27 @@ import (
 func init() {
 	domainCmd.AddCommand(domainZoneImportCmd)
 	domainZoneImportCmd.Flags().BoolVarP(&domainZoneImportYes, "yes", "y", false, "Confirm import of file without prompt")
+	domainZoneImportCmd.Flags().StringVar(&domainZoneImportFormat, "format", "auto", "Format of the file, one of auto, "+strings.Join(zoneImportFormats, ", "))
 }
 
 var domainZoneImportYes bool
+var domainZoneImportFormat string
 var domainZoneImportCmd = &cobra.Command{
 	Use:   "zoneimport <domain> <zone file>",
 	Short: "Import DNS records for a domain from a zone file",
 	Long: `Import DNS records for a domain from a zone file.
 Existing records (same name/type) will be replaced by the new records.
-Pass '-' as file name to read from stdin.`,
+Pass '-' as file name to read from stdin.
+
+Besides BIND zone files, the following export formats from other providers can be imported:
+* cloudflare: JSON from the Cloudflare DNS records API (either the full response or just the result array).
+* route53:    JSON from "aws route53 list-resource-record-sets".
+* csv:        CSV with a header row containing at least name, type and content (or value) columns,
+              and optionally a priority column. Host names containing a dot are taken to be fully qualified.
+The format is detected automatically from the file content unless given with --format.`,
+	Example: `lvl domain zoneimport example.com example.com.zone
+aws route53 list-resource-record-sets --hosted-zone-id Z123 | lvl domain zoneimport example.com -
+lvl domain zoneimport example.com records.csv --format csv`,
 
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -33,6 +47,15 @@ Pass '-' as file name to read from stdin.`,
 
 		defer file.Close()
 
+		if domainZoneImportFormat != "auto" && !sliceContains(zoneImportFormats, domainZoneImportFormat) {
+			return fmt.Errorf("unknown format: '%s'", domainZoneImportFormat)
+		}
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return fmt.Errorf("failed to read input: %s", err.Error())
+		}
+
 		domainID, err := resolveDomain(args[0])
 		if err != nil {
 			return err
@@ -50,9 +73,20 @@ Pass '-' as file name to read from stdin.`,
 
 		origin := fmt.Sprintf("%s.", domain.Fullname)
 
+		format := domainZoneImportFormat
+		if format == "auto" {
+			format = zoneImportDetectFormat(data)
+			fmt.Printf("Detected format: %s\n", format)
+		}
+
+		zone, err := zoneImportConvert(format, origin, data)
+		if err != nil {
+			return err
+		}
+
 		// Build index to find records to replace.
 		existingRecordsIndex := zoneImportMakeExistingRecordsIndex(existingRecords)
-		toReplace, toCreate := zoneDomainImportParse(origin, file, existingRecordsIndex)
+		toReplace, toCreate := zoneDomainImportParse(origin, bytes.NewReader(zone), existingRecordsIndex)
 
 		fmt.Printf(
 			"%d existing records to delete (for replacement)\n%d records to create\n",
@@ -161,49 +195,13 @@ func zoneDomainImportParse(
 				toReplace[id] = true
 			}
 
-			request := l27.DomainRecordRequest{
-				Type: rr.Type.String(),
-				Name: finalName,
-			}
-
-			if request.Name == "@" {
-				request.Name = ""
+			if finalName == "@" {
+				finalName = ""
 			}
 
-			switch rr.Type {
-			case utils.RecordTypeA:
-				request.Content = rr.Data[0]
-			case utils.RecordTypeAAAA:
-				request.Content = rr.Data[0]
-			case utils.RecordTypeMX:
-				priority, err := strconv.ParseInt(rr.Data[0], 10, 32)
-				if err != nil {
-					fmt.Printf("Invalid priority in MX record: '%s'\n", rr.Data[0])
-					continue
-				}
-
-				request.Priority = int32(priority)
-				request.Content = rr.Data[1]
-			case utils.RecordTypeTXT:
-				request.Content = strings.Join(rr.Data, "")
-			case utils.RecordTypeCNAME:
-				request.Content = rr.Data[0]
-			case utils.RecordTypeNS:
-				if request.Name == "" {
-					fmt.Printf("Note: NS record at domain origin ignored.\n")
-					continue
-				}
-				request.Content = rr.Data[0]
-			case utils.RecordTypeSRV:
-				request.Content = strings.Join(rr.Data, " ")
-			case utils.RecordTypeTLSA:
-				request.Content = strings.Join(rr.Data, " ")
-			case utils.RecordTypeCAA:
-				request.Content = strings.Join(rr.Data, " ")
-			case utils.RecordTypeDS:
-				request.Content = strings.Join(rr.Data, " ")
-			default:
-				fmt.Printf("Note: Level27 does not support importing %v records, ignoring.\n", rr.Type)
+			request, err := zoneRecordRequest(rr.Type, finalName, rr.Data)
+			if err != nil {
+				fmt.Printf("Note: %s.\n", err.Error())
 				continue
 			}
 
@@ -214,41 +212,62 @@ func zoneDomainImportParse(
 	return toReplace, toCreate
 }
 
-func zoneDomainNormalizeOrigin(domain string, curOrigin string, destOrigin string) string {
-	concat := zoneDomainConcat(domain, curOrigin)
-	return zoneDomainRelative(concat, destOrigin)
-}
-
-// Make a domain absolute by appending the origin (if it's not yet absolute).
-func zoneDomainConcat(domain string, origin string) string {
-	if strings.HasSuffix(domain, ".") {
-		return domain
+// Convert the data of a zone file record to a request for the API.
+// name must be relative to the domain, with an empty string for the domain itself.
+func zoneRecordRequest(recordType utils.RecordType, name string, data []string) (l27.DomainRecordRequest, error) {
+	request := l27.DomainRecordRequest{
+		Type: recordType.String(),
+		Name: name,
 	}
 
-	if domain == "@" {
-		return origin
+	if len(data) == 0 {
+		return request, fmt.Errorf("%v record '%s' has no data, ignoring", recordType, name)
 	}
 
-	return fmt.Sprintf("%s.%s", domain, origin)
-}
+	switch recordType {
+	case utils.RecordTypeA:
+		request.Content = data[0]
+	case utils.RecordTypeAAAA:
+		request.Content = data[0]
+	case utils.RecordTypeMX:
+		if len(data) != 2 {
+			return request, fmt.Errorf("MX record '%s' must have a priority and a target, ignoring", name)
+		}
 
-// Make a domain relative again by splitting off the
-// "xyz.foo.bar.baz.", "bar.baz." -> "xyz.foo"
-// "bar.baz.", "bar.baz."         -> "@"
-// "abc.xyz.", "bar.baz."         -> "abc.xyz."
-func zoneDomainRelative(domain string, origin string) string {
-	if domain == origin {
-		// Same domain as origin
-		return "@"
-	}
+		priority, err := strconv.ParseInt(data[0], 10, 32)
+		if err != nil {
+			return request, fmt.Errorf("invalid priority in MX record: '%s'", data[0])
+		}
 
-	if strings.HasSuffix(domain, fmt.Sprintf(".%s", origin)) {
-		// Subdomain of origin
-		return domain[:len(domain)-len(origin)-1]
+		request.Priority = int32(priority)
+		request.Content = data[1]
+	case utils.RecordTypeTXT:
+		request.Content = strings.Join(data, "")
+	case utils.RecordTypeCNAME:
+		request.Content = data[0]
+	case utils.RecordTypeNS:
+		if request.Name == "" {
+			return request, errors.New("NS record at domain origin ignored")
+		}
+		request.Content = data[0]
+	case utils.RecordTypeSRV:
+		request.Content = strings.Join(data, " ")
+	case utils.RecordTypeTLSA:
+		request.Content = strings.Join(data, " ")
+	case utils.RecordTypeCAA:
+		request.Content = strings.Join(data, " ")
+	case utils.RecordTypeDS:
+		request.Content = strings.Join(data, " ")
+	default:
+		return request, fmt.Errorf("Level27 does not support importing %v records, ignoring", recordType)
 	}
 
-	// Not related at all
-	return domain
+	return request, nil
+}
+
+func zoneDomainNormalizeOrigin(domain string, curOrigin string, destOrigin string) string {
+	concat := utils.ZoneDomainConcat(domain, curOrigin)
+	return utils.ZoneDomainRelative(concat, destOrigin)
 }
 
 func zoneImportMakeExistingRecordsIndex(records []l27.DomainRecord) map[zoneImportingExistingRecord][]l27.IntID {
@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Conversion of other providers' DNS export formats to zone files,
+// so they can be imported through the same code as regular zone files.
+
+var zoneImportFormats = []string{"bind", "cloudflare", "route53", "csv"}
+
+// Guess the format of a file to import from its contents.
+func zoneImportDetectFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) != 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		if bytes.Contains(trimmed, []byte(`"ResourceRecordSets"`)) {
+			return "route53"
+		}
+
+		return "cloudflare"
+	}
+
+	// CSV exports start with a header row naming the columns.
+	firstLine := strings.SplitN(string(trimmed), "\n", 2)[0]
+	header, err := csv.NewReader(strings.NewReader(firstLine)).Read()
+	if err == nil {
+		columns := zoneImportCsvColumns(header)
+		if _, ok := columns["name"]; ok {
+			if _, ok := columns["type"]; ok {
+				return "csv"
+			}
+		}
+	}
+
+	return "bind"
+}
+
+// Convert a file to import to a zone file.
+func zoneImportConvert(format string, origin string, data []byte) ([]byte, error) {
+	switch format {
+	case "bind":
+		return data, nil
+	case "cloudflare":
+		return zoneImportConvertCloudflare(origin, data)
+	case "route53":
+		return zoneImportConvertRoute53(data)
+	case "csv":
+		return zoneImportConvertCsv(origin, data)
+	default:
+		return nil, fmt.Errorf("unknown format: '%s'", format)
+	}
+}
+
+// Write a single record to a zone file being built.
+func zoneImportWriteRecord(buf *bytes.Buffer, origin string, name string, recordType string, priority string, content string) {
+	fmt.Fprintf(
+		buf,
+		"%s IN %s %s\n",
+		zoneImportRecordName(name, origin),
+		strings.ToUpper(recordType),
+		zoneImportRecordData(recordType, priority, content))
+}
+
+// Most providers export either fully qualified names without trailing dot, or names relative to the domain.
+func zoneImportRecordName(name string, origin string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || name == "@" {
+		return "@"
+	}
+
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+
+	domain := strings.TrimSuffix(origin, ".")
+	if name == domain || strings.HasSuffix(name, "."+domain) {
+		return name + "."
+	}
+
+	return name
+}
+
+// Most providers export the priority of MX/SRV records separately, TXT records without quotes,
+// and host names without trailing dot.
+func zoneImportRecordData(recordType string, priority string, content string) string {
+	content = strings.TrimSpace(content)
+	switch strings.ToUpper(recordType) {
+	case "CNAME", "NS":
+		return zoneImportAbsoluteTarget(content)
+	case "MX":
+		if priority != "" && len(strings.Fields(content)) == 1 {
+			content = priority + " " + content
+		}
+
+		return zoneImportAbsoluteTarget(content)
+	case "SRV":
+		if priority != "" && len(strings.Fields(content)) == 3 {
+			content = priority + " " + content
+		}
+
+		return zoneImportAbsoluteTarget(content)
+	case "TXT":
+		if !strings.HasPrefix(content, `"`) {
+			return domainRecordEditZoneData("TXT", 0, content)
+		}
+	}
+
+	return content
+}
+
+// Make the host name at the end of the record data absolute, if it contains a dot.
+// Names without a dot are left relative to the domain.
+func zoneImportAbsoluteTarget(content string) string {
+	if strings.Contains(content[strings.LastIndex(content, " ")+1:], ".") && !strings.HasSuffix(content, ".") {
+		return content + "."
+	}
+
+	return content
+}
+
+type zoneImportCloudflareRecord struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Content  string `json:"content"`
+	Priority *int   `json:"priority"`
+}
+
+// Cloudflare: the JSON response of the DNS records API (GET /zones/:zone_identifier/dns_records).
+func zoneImportConvertCloudflare(origin string, data []byte) ([]byte, error) {
+	var records []zoneImportCloudflareRecord
+
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) != 0 && trimmed[0] == '[' {
+		err := json.Unmarshal(trimmed, &records)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Cloudflare JSON: %s", err.Error())
+		}
+	} else {
+		var response struct {
+			Result []zoneImportCloudflareRecord `json:"result"`
+		}
+
+		err := json.Unmarshal(trimmed, &response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Cloudflare JSON: %s", err.Error())
+		}
+
+		records = response.Result
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "$ORIGIN %s\n", origin)
+	for _, record := range records {
+		priority := ""
+		if record.Priority != nil {
+			priority = strconv.Itoa(*record.Priority)
+		}
+
+		zoneImportWriteRecord(&buf, origin, record.Name, record.Type, priority, record.Content)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Route 53: the output of "aws route53 list-resource-record-sets".
+// Values are already in zone file format.
+func zoneImportConvertRoute53(data []byte) ([]byte, error) {
+	var export struct {
+		ResourceRecordSets []struct {
+			Name            string
+			Type            string
+			ResourceRecords []struct {
+				Value string
+			}
+			AliasTarget *struct {
+				DNSName string
+			}
+		}
+	}
+
+	err := json.Unmarshal(data, &export)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Route 53 JSON: %s", err.Error())
+	}
+
+	var buf bytes.Buffer
+	for _, set := range export.ResourceRecordSets {
+		if set.AliasTarget != nil {
+			fmt.Printf("Note: Route 53 alias records cannot be imported, ignoring %s %s (alias to %s).\n", set.Type, set.Name, set.AliasTarget.DNSName)
+			continue
+		}
+
+		for _, record := range set.ResourceRecords {
+			fmt.Fprintf(&buf, "%s IN %s %s\n", set.Name, set.Type, record.Value)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// CSV: a header row with name, type and content (or value/data) columns, and optionally priority.
+func zoneImportConvertCsv(origin string, data []byte) ([]byte, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %s", err.Error())
+	}
+
+	columns := zoneImportCsvColumns(header)
+	for _, required := range []string{"name", "type", "content"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV header has no '%s' column", required)
+		}
+	}
+
+	field := func(row []string, column string) string {
+		idx, ok := columns[column]
+		if !ok || idx >= len(row) {
+			return ""
+		}
+
+		return strings.TrimSpace(row[idx])
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "$ORIGIN %s\n", origin)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %s", err.Error())
+		}
+
+		zoneImportWriteRecord(&buf, origin, field(row, "name"), field(row, "type"), field(row, "priority"), field(row, "content"))
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Map the columns of a CSV header to their index. Common alternative column names are mapped to the same column.
+func zoneImportCsvColumns(header []string) map[string]int {
+	aliases := map[string]string{
+		"host":     "name",
+		"hostname": "name",
+		"record":   "name",
+		"value":    "content",
+		"data":     "content",
+		"target":   "content",
+		"prio":     "priority",
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if alias, ok := aliases[column]; ok {
+			column = alias
+		}
+
+		if _, ok := columns[column]; !ok {
+			columns[column] = i
+		}
+	}
+
+	return columns
+}
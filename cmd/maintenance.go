@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// MAINTENANCE
+	RootCmd.AddCommand(maintenanceCmd)
+
+	// SCHEDULE
+	maintenanceCmd.AddCommand(maintenanceScheduleCmd)
+	flags := maintenanceScheduleCmd.Flags()
+	flags.StringSliceVar(&maintenanceScheduleSystems, "systems", nil, "Systems to plan maintenance for (e.g. web1,web2)")
+	flags.StringVar(&maintenanceScheduleGroup, "group", "", "Plan maintenance for all systems in this systemgroup")
+	flags.StringVar(&maintenanceScheduleAt, "at", "", "When maintenance starts, e.g. 2026-11-01T02:00 (local time)")
+	flags.DurationVar(&maintenanceScheduleDuration, "duration", 0, "How long maintenance lasts, e.g. 2h")
+	maintenanceScheduleCmd.MarkFlagRequired("at")
+	maintenanceScheduleCmd.MarkFlagRequired("duration")
+
+	// LIST
+	maintenanceCmd.AddCommand(maintenanceListCmd)
+	maintenanceListCmd.Flags().BoolVar(&maintenanceListAll, "all", false, "Also list finished, missed and cancelled maintenance")
+
+	// RUN
+	maintenanceCmd.AddCommand(maintenanceRunCmd)
+	flags = maintenanceRunCmd.Flags()
+	flags.BoolVar(&maintenanceRunDaemon, "daemon", false, "Keep running, checking the schedule every --interval")
+	flags.DurationVar(&maintenanceRunInterval, "interval", time.Minute, "With --daemon, how often to check the schedule")
+
+	// CANCEL
+	maintenanceCmd.AddCommand(maintenanceCancelCmd)
+}
+
+// File maintenance windows are stored in.
+func maintenanceScheduleFile() string {
+	return filepath.Join(lvlDataDir(), "maintenance.yaml")
+}
+
+// MAINTENANCE
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Plan maintenance windows for systems ahead of time",
+	Long: `Plan maintenance windows for systems ahead of time.
+Maintenance windows are kept locally (in ~/.lvl/maintenance.yaml, next to the config file), and started and stopped by 'lvl maintenance run'.
+Run it as a daemon with --daemon, or every minute from cron:
+  * * * * * lvl maintenance run`,
+}
+
+// SCHEDULE
+var maintenanceScheduleSystems []string
+var maintenanceScheduleGroup string
+var maintenanceScheduleAt string
+var maintenanceScheduleDuration time.Duration
+var maintenanceScheduleCmd = &cobra.Command{
+	Use:   "schedule (--systems <system,...> | --group <systemgroup>) --at <time> --duration <duration>",
+	Short: "Plan maintenance for systems",
+	Example: `lvl maintenance schedule --systems web1,web2 --at 2026-11-01T02:00 --duration 2h
+lvl maintenance schedule --group webservers --at '2026-11-01 02:00' --duration 30m`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		start, err := utils.ParseMaintenanceTime(maintenanceScheduleAt, time.Local)
+		if err != nil {
+			return err
+		}
+
+		if maintenanceScheduleDuration < time.Minute {
+			return fmt.Errorf("--duration must be at least a minute")
+		}
+
+		end := start.Add(maintenanceScheduleDuration)
+		if !end.After(time.Now()) {
+			return fmt.Errorf("maintenance would already be over at %s", end.Format("2006-01-02 15:04"))
+		}
+
+		systems, err := resolveSystemSelection(maintenanceScheduleGroup, maintenanceScheduleSystems)
+		if err != nil {
+			return err
+		}
+
+		unlock, err := utils.LockMaintenanceSchedule(maintenanceScheduleFile())
+		if err != nil {
+			return err
+		}
+
+		defer unlock()
+
+		windows, err := utils.LoadMaintenanceSchedule(maintenanceScheduleFile())
+		if err != nil {
+			return err
+		}
+
+		window := utils.MaintenanceWindow{
+			ID:    utils.NextMaintenanceID(windows),
+			Start: start,
+			End:   end,
+			State: utils.MaintenancePlanned,
+		}
+
+		for _, system := range systems {
+			window.Systems = append(window.Systems, utils.MaintenanceSystem{ID: int(system.ID), Name: system.Name})
+		}
+
+		windows = append(windows, window)
+		err = utils.SaveMaintenanceSchedule(maintenanceScheduleFile(), windows)
+		if err != nil {
+			return err
+		}
+
+		outputFormatTemplate(window, "templates/entities/maintenance/schedule.tmpl")
+		return nil
+	},
+}
+
+// LIST
+var maintenanceListAll bool
+var maintenanceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List active and upcoming maintenance",
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// No lock needed, the schedule is always replaced at once.
+		windows, err := utils.LoadMaintenanceSchedule(maintenanceScheduleFile())
+		if err != nil {
+			return err
+		}
+
+		shown := []utils.MaintenanceWindow{}
+		for _, window := range windows {
+			if maintenanceListAll || window.Pending() {
+				shown = append(shown, window)
+			}
+		}
+
+		formatTime := func(t time.Time) string { return t.Local().Format("2006-01-02 15:04") }
+		outputFormatTableFuncs(
+			shown,
+			[]string{"ID", "STATUS", "START", "END", "SYSTEMS", "ERROR"},
+			[]interface{}{
+				"ID",
+				"State",
+				func(w utils.MaintenanceWindow) string { return formatTime(w.Start) },
+				func(w utils.MaintenanceWindow) string { return formatTime(w.End) },
+				func(w utils.MaintenanceWindow) string {
+					names := []string{}
+					for _, system := range w.Systems {
+						names = append(names, system.Name)
+					}
+
+					return strings.Join(names, ", ")
+				},
+				"Error",
+			})
+
+		return nil
+	},
+}
+
+// RUN
+var maintenanceRunDaemon bool
+var maintenanceRunInterval time.Duration
+var maintenanceRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Start and stop planned maintenance that is due",
+	Long: `Start and stop planned maintenance that is due.
+Without --daemon, the schedule is checked once, which is meant to be run every minute from cron.
+Maintenance that ended before it could be started is marked as missed.`,
+	Example: `lvl maintenance run
+lvl maintenance run --daemon`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !maintenanceRunDaemon {
+			return runMaintenanceSchedule(time.Now())
+		}
+
+		if maintenanceRunInterval < time.Second {
+			return fmt.Errorf("--interval must be at least a second")
+		}
+
+		interrupted := make(chan os.Signal, 1)
+		signal.Notify(interrupted, os.Interrupt)
+		defer signal.Stop(interrupted)
+
+		for {
+			// Errors are reported but don't stop the daemon, the next run may do better.
+			if err := runMaintenanceSchedule(time.Now()); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", time.Now().Format(time.RFC3339), err.Error())
+			}
+
+			select {
+			case <-interrupted:
+				return nil
+			case <-time.After(maintenanceRunInterval):
+			}
+		}
+	},
+}
+
+// Check the maintenance schedule once, starting and stopping maintenance that is due at the given time.
+func runMaintenanceSchedule(now time.Time) error {
+	// Held while maintenance is started and stopped, so a cancel in the meantime isn't overwritten.
+	unlock, err := utils.LockMaintenanceSchedule(maintenanceScheduleFile())
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	windows, err := utils.LoadMaintenanceSchedule(maintenanceScheduleFile())
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for i := range windows {
+		window := &windows[i]
+		action := window.Due(now)
+		if action == utils.MaintenanceActionNone {
+			continue
+		}
+
+		changed = true
+		switch action {
+		case utils.MaintenanceActionStart:
+			minutes := window.MinutesLeft(now)
+			window.Error = maintenanceForSystems(window, "start", func(systemID l27.IntID) error {
+				_, err := Level27Client.SystemActionStartMaintenance(systemID, minutes)
+				return err
+			})
+			window.State = utils.MaintenanceActive
+		case utils.MaintenanceActionStop:
+			window.Error = maintenanceForSystems(window, "stop", stopSystemMaintenance)
+			window.State = utils.MaintenanceDone
+		case utils.MaintenanceActionMiss:
+			fmt.Fprintf(os.Stderr, "%s: maintenance %d ended before it could be started\n", now.Format(time.RFC3339), window.ID)
+			window.State = utils.MaintenanceMissed
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return utils.SaveMaintenanceSchedule(maintenanceScheduleFile(), windows)
+}
+
+// Run a maintenance action on all systems of a window.
+// Systems that fail don't stop the others, their errors are returned as a single message.
+func maintenanceForSystems(window *utils.MaintenanceWindow, action string, run func(systemID l27.IntID) error) string {
+	tasks := []<-chan error{}
+	for _, system := range window.Systems {
+		system := system
+		tasks = append(tasks, taskRunVoid(func() error { return run(l27.IntID(system.ID)) }))
+	}
+
+	errs := []string{}
+	for i, task := range tasks {
+		system := window.Systems[i]
+		if err := <-task; err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", system.Name, err.Error()))
+			fmt.Fprintf(os.Stderr, "%s: failed to %s maintenance %d on %s: %s\n", time.Now().Format(time.RFC3339), action, window.ID, system.Name, err.Error())
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %s maintenance %d on %s\n", time.Now().Format(time.RFC3339), action, window.ID, system.Name)
+		}
+	}
+
+	return strings.Join(errs, "; ")
+}
+
+func stopSystemMaintenance(systemID l27.IntID) error {
+	_, err := Level27Client.SystemAction(systemID, "stopMaintenance")
+	return err
+}
+
+// CANCEL
+var maintenanceCancelCmd = &cobra.Command{
+	Use:   "cancel <ID>",
+	Short: "Cancel planned maintenance, stopping it if it is active",
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid maintenance ID: '%s'", args[0])
+		}
+
+		unlock, err := utils.LockMaintenanceSchedule(maintenanceScheduleFile())
+		if err != nil {
+			return err
+		}
+
+		defer unlock()
+
+		windows, err := utils.LoadMaintenanceSchedule(maintenanceScheduleFile())
+		if err != nil {
+			return err
+		}
+
+		for i := range windows {
+			window := &windows[i]
+			if window.ID != id {
+				continue
+			}
+
+			if !window.Pending() {
+				return fmt.Errorf("maintenance %d is already %s", id, window.State)
+			}
+
+			if window.State == utils.MaintenanceActive {
+				window.Error = maintenanceForSystems(window, "stop", stopSystemMaintenance)
+			}
+
+			window.State = utils.MaintenanceCancelled
+			err = utils.SaveMaintenanceSchedule(maintenanceScheduleFile(), windows)
+			if err != nil {
+				return err
+			}
+
+			outputFormatTemplate(window, "templates/entities/maintenance/cancel.tmpl")
+			return nil
+		}
+
+		return fmt.Errorf("unable to find maintenance %d", id)
+	},
+}
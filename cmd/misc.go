@@ -3,6 +3,8 @@ package cmd
 import (
 	"os"
 	"os/exec"
+	"runtime"
+	"strings"
 )
 
 // Finds the index of an element within a slice. Returns -1 if the element is not present.
@@ -65,6 +67,30 @@ func tailExecProcess(command string, args []string) error {
 	return runErr
 }
 
+// Open a file in the user's text editor ($VISUAL or $EDITOR) and wait for it to be closed.
+func runEditor(fileName string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" {
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "vi"
+		}
+	}
+
+	// Editors are commonly configured with arguments, e.g. "code --wait".
+	parts := strings.Fields(editor)
+	editorCmd := exec.Command(parts[0], append(parts[1:], fileName)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	return editorCmd.Run()
+}
+
 type tuple2[T1 any, T2 any] struct {
 	Item1 T1
 	Item2 T2
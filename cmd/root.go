@@ -17,6 +17,7 @@ package cmd
 
 import (
 	"embed"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -64,7 +65,7 @@ var RootCmd = &cobra.Command{
 
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		outputSet := viper.GetString("output")
-		if outputSet != "text" && outputSet != "json" && output != "yaml" && output != "id" {
+		if outputSet != "text" && outputSet != "json" && output != "yaml" && output != "id" && outputSet != "csv" {
 			return fmt.Errorf("invalid output mode specified: '%s'", outputSet)
 		}
 
@@ -102,7 +103,7 @@ func init() {
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.lvl.yaml)")
 	RootCmd.PersistentFlags().StringVar(&apiKey, "apikey", "", "API key")
 	RootCmd.PersistentFlags().BoolVar(&traceRequests, "trace", false, "Do detailed network request logging. This is intended for debugging and should not be parsed.")
-	RootCmd.PersistentFlags().StringVarP(&output, "output", "o", "text", "Specifies output mode for commands. Accepted values are 'text', 'json', 'yaml' or 'id'.")
+	RootCmd.PersistentFlags().StringVarP(&output, "output", "o", "text", "Specifies output mode for commands. Accepted values are 'text', 'json', 'yaml', 'id' or 'csv' (lists only).")
 
 	viper.BindPFlag("config", RootCmd.PersistentFlags().Lookup("config"))
 	viper.BindPFlag("apikey", RootCmd.PersistentFlags().Lookup("apikey"))
@@ -205,12 +206,12 @@ func isViperConfigNotFound(err error) bool {
 func outputFormatTable(objects interface{}, titles []string, fields []string) {
 	outputMode := viper.GetString("output")
 	switch outputMode {
-	case "text":
+	case "text", "csv":
 		fieldsInterface := make([]interface{}, len(fields))
 		for i := range fields {
 			fieldsInterface[i] = fields[i]
 		}
-		outputFormatTableText(objects, titles, fieldsInterface)
+		outputFormatTableFuncs(objects, titles, fieldsInterface)
 	case "json":
 		outputFormatTableJson(objects)
 	case "yaml":
@@ -229,6 +230,8 @@ func outputFormatTableFuncs(objects interface{}, titles []string, fields []inter
 	switch outputMode {
 	case "text":
 		outputFormatTableText(objects, titles, fields)
+	case "csv":
+		outputFormatTableCsv(objects, titles, fields)
 	case "json":
 		outputFormatTableJson(objects)
 	case "yaml":
@@ -246,7 +249,8 @@ func outputFormatTableFuncs(objects interface{}, titles []string, fields []inter
 func outputFormatTemplate(object interface{}, templatePath string) {
 	outputMode := viper.GetString("output")
 	switch outputMode {
-	case "text":
+	case "text", "csv":
+		// CSV only applies to tabular data, show single objects as text.
 		outputFormatTemplateText(object, templatePath)
 	case "json":
 		outputFormatTemplateJson(object)
@@ -258,6 +262,42 @@ func outputFormatTemplate(object interface{}, templatePath string) {
 }
 
 func outputFormatTableText(objects interface{}, titles []string, fields []interface{}) {
+	w := tabwriter.NewWriter(os.Stdout, 4, 8, 4, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, strings.Join(titles, "\t"))
+
+	for _, row := range outputTableRows(objects, fields) {
+		for i, value := range row {
+			if i != 0 {
+				fmt.Fprintf(w, "\t")
+			}
+
+			fmt.Fprintf(w, "%v", value)
+		}
+
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+func outputFormatTableCsv(objects interface{}, titles []string, fields []interface{}) {
+	w := csv.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	w.Write(titles)
+
+	for _, row := range outputTableRows(objects, fields) {
+		record := make([]string, len(row))
+		for i, value := range row {
+			record[i] = fmt.Sprint(value)
+		}
+
+		w.Write(record)
+	}
+}
+
+// Get the values of every column for every object in a table. See outputFormatTableFuncs.
+func outputTableRows(objects interface{}, fields []interface{}) [][]interface{} {
 	// Have to use reflection for this because no generics in go (yet).
 	s := reflect.ValueOf(objects)
 
@@ -265,16 +305,12 @@ func outputFormatTableText(objects interface{}, titles []string, fields []interf
 		panic("outputFormatTable must be given a slice!")
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 4, 8, 4, ' ', 0)
-	defer w.Flush()
-
-	fmt.Fprintln(w, strings.Join(titles, "\t"))
-
+	rows := make([][]interface{}, s.Len())
 	for i := 0; i < s.Len(); i++ {
 		val := s.Index(i)
 
-		first := true
-		for _, field := range fields {
+		row := make([]interface{}, len(fields))
+		for j, field := range fields {
 			var fld reflect.Value
 			if fieldPath, isString := field.(string); isString {
 				fld = val
@@ -287,10 +323,6 @@ func outputFormatTableText(objects interface{}, titles []string, fields []interf
 				fld = reflect.ValueOf(field).Call([]reflect.Value{val})[0]
 			}
 
-			if !first {
-				fmt.Fprintf(w, "\t")
-			}
-
 			fldInterface := fld.Interface()
 
 			// Try to present localized strings by showing the English name.
@@ -300,12 +332,13 @@ func outputFormatTableText(objects interface{}, titles []string, fields []interf
 				}
 			}
 
-			first = false
-			fmt.Fprintf(w, "%v", fldInterface)
+			row[j] = fldInterface
 		}
 
-		fmt.Fprintf(w, "\n")
+		rows[i] = row
 	}
+
+	return rows
 }
 
 func outputFormatTableJson(objects interface{}) {
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// SYSTEM ADDRESS
+	systemCmd.AddCommand(systemAddressCmd)
+	addSshFlags(systemAddressCmd)
+}
+
+// Address of a system as listed by lvl system address.
+type systemAddressRow struct {
+	systemAddress
+	// How the address is used by lvl system ssh, empty if it's not.
+	Ssh string `json:"ssh"`
+}
+
+// SYSTEM ADDRESS
+var systemAddressCmd = &cobra.Command{
+	Use:   "address <system>",
+	Short: "List the addresses of a system, and which one lvl system ssh connects to",
+	Long: `List the addresses of a system, and which one lvl system ssh connects to.
+The SSH flags (--ipv6, --internal, --network, --jump) change the address that is picked, like they do for 'lvl system ssh'.`,
+	Example: `lvl system address my-awesome-server
+lvl system address my-awesome-server --ipv6`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		systemID, err := resolveSystem(args[0])
+		if err != nil {
+			return err
+		}
+
+		system, err := Level27Client.SystemGetSingle(systemID)
+		if err != nil {
+			return err
+		}
+
+		// The addresses are listed even if none matches the SSH flags, the SSH column just stays empty.
+		target, err := prepareSshTarget(systemID, 0, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lvl system ssh can't connect: %s\n", err.Error())
+		}
+
+		addresses := append(
+			[]systemAddress{{Type: "fqdn", Address: system.Fqdn}},
+			getSystemAddresses(system)...)
+
+		rows := []systemAddressRow{}
+		for _, address := range addresses {
+			row := systemAddressRow{systemAddress: address}
+			if err == nil && address.Address == target.Host {
+				row.Ssh = "yes"
+				if target.Jump != "" {
+					row.Ssh = "via " + target.Jump
+				}
+			}
+
+			rows = append(rows, row)
+		}
+
+		outputFormatTable(
+			rows,
+			[]string{"NETWORK", "TYPE", "FAMILY", "ADDRESS", "SSH"},
+			[]string{"Network", "Type", "Family", "Address", "Ssh"})
+
+		return nil
+	},
+}
@@ -466,19 +466,11 @@ If --wait is passed, this command will wait for all pending cookbook changes to
 			return err
 		}
 
-		// Get all the cookbooks on the system that have a pending change.
-		cookbooks, err := Level27Client.SystemCookbookGetList(systemID, l27.CommonGetParams{})
+		toApply, err := getPendingCookbooks(systemID)
 		if err != nil {
 			return err
 		}
 
-		toApply := []l27.Cookbook{}
-		for _, cookbook := range cookbooks {
-			if cookbook.Status == "to_update" || cookbook.Status == "to_delete" || cookbook.Status == "to_create" {
-				toApply = append(toApply, cookbook)
-			}
-		}
-
 		if len(toApply) == 0 {
 			outputFormatTemplate(nil, "templates/entities/systemCookbook/applyNoPending.tmpl")
 			return nil
@@ -502,6 +494,23 @@ If --wait is passed, this command will wait for all pending cookbook changes to
 	},
 }
 
+// Get all the cookbooks on a system that have a pending change.
+func getPendingCookbooks(systemID l27.IntID) ([]l27.Cookbook, error) {
+	cookbooks, err := Level27Client.SystemCookbookGetList(systemID, l27.CommonGetParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []l27.Cookbook{}
+	for _, cookbook := range cookbooks {
+		if cookbook.Status == "to_update" || cookbook.Status == "to_delete" || cookbook.Status == "to_create" {
+			pending = append(pending, cookbook)
+		}
+	}
+
+	return pending, nil
+}
+
 // Poll on status updates from many cookbooks at once.
 // If any cookbook gets an invalid status for its original status, an error occurs.
 // Takes in the original cookbooks from before an apply operating,
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// SYSTEM COOKBOOKS COPY
+	systemCookbookCmd.AddCommand(systemCookbookCopyCmd)
+	addWaitFlag(systemCookbookCopyCmd)
+	flags := systemCookbookCopyCmd.Flags()
+	flags.StringSliceVar(&systemCookbookCopyTypes, "types", nil, "Only copy cookbooks of these types (e.g. php,nginx)")
+	flags.BoolVar(&cookbookDeferApply, "defer", false, "Defer applying changes to cookbooks for lvl system cookbooks apply")
+}
+
+// A cookbook copied by lvl system cookbooks copy, as shown in its output.
+type systemCookbookCopyResult struct {
+	CookbookType string `json:"cookbookType"`
+	Action       string `json:"action"`
+	// Parameters that were not copied because they refer to the source system.
+	Skipped string `json:"skipped"`
+}
+
+// A cookbook to add or update on the target system.
+type systemCookbookCopyChange struct {
+	CookbookID l27.IntID
+	Request    l27.CookbookRequest
+	Result     systemCookbookCopyResult
+}
+
+// SYSTEM COOKBOOKS COPY
+var systemCookbookCopyTypes []string
+var systemCookbookCopyCmd = &cobra.Command{
+	Use:   "copy <source system> <target system>",
+	Short: "Copy cookbooks and their parameters from one system to another",
+	Long: `Copy cookbooks and their parameters from one system to another.
+Cookbooks that are missing on the target system are added, cookbooks it already has are updated.
+Parameters that refer to the source system (e.g. its hostname or IP addresses) are not copied, and listed under SKIPPED so they can be set by hand.
+All cookbooks are checked against the operating system of the target system before anything is changed, and applied at once at the end.`,
+	Example: `lvl system cookbooks copy web1 web2
+lvl system cookbooks copy web1 web2 --types php,nginx --wait`,
+
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourceID, err := resolveSystem(args[0])
+		if err != nil {
+			return err
+		}
+
+		targetID, err := resolveSystem(args[1])
+		if err != nil {
+			return err
+		}
+
+		if sourceID == targetID {
+			return fmt.Errorf("source and target are the same system")
+		}
+
+		changes, err := planSystemCookbookCopy(sourceID, targetID)
+		if err != nil {
+			return err
+		}
+
+		results := []systemCookbookCopyResult{}
+		for _, change := range changes {
+			change := change
+			if change.CookbookID == 0 {
+				_, err = Level27Client.SystemCookbookAdd(targetID, &change.Request)
+			} else {
+				err = Level27Client.SystemCookbookUpdate(targetID, change.CookbookID, &change.Request)
+			}
+
+			if err != nil {
+				// Show what was already changed, so it's clear where the copy stopped.
+				outputFormatTable(results, []string{"COOKBOOKTYPE", "ACTION", "SKIPPED"}, []string{"CookbookType", "Action", "Skipped"})
+				return fmt.Errorf("failed to %s cookbook %s: %s", change.Result.Action, change.Request.Cookbooktype, err.Error())
+			}
+
+			results = append(results, change.Result)
+		}
+
+		outputFormatTable(results, []string{"COOKBOOKTYPE", "ACTION", "SKIPPED"}, []string{"CookbookType", "Action", "Skipped"})
+
+		if cookbookDeferApply || len(changes) == 0 {
+			return nil
+		}
+
+		toApply, err := getPendingCookbooks(targetID)
+		if err != nil {
+			return err
+		}
+
+		err = Level27Client.SystemCookbookChangesApply(targetID)
+		if err != nil {
+			return err
+		}
+
+		if optWait {
+			err = pollMultiCookbooksApplyWait(toApply)
+			if err != nil {
+				return fmt.Errorf("waiting on system cookbook status failed: %s", err.Error())
+			}
+		}
+
+		return nil
+	},
+}
+
+// Work out the cookbooks to add or update on the target system, validating them all up front.
+func planSystemCookbookCopy(sourceID l27.IntID, targetID l27.IntID) ([]systemCookbookCopyChange, error) {
+	source, err := Level27Client.SystemGetSingle(sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := Level27Client.SystemGetSingle(targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetOS := fmt.Sprintf("%v %v", target.OperatingSystemVersion.OsName, target.OperatingSystemVersion.OsVersion)
+
+	// Anything mentioning these is specific to the source system.
+	identifiers := []string{source.Name, source.Fqdn}
+	for _, address := range getSystemAddresses(source) {
+		identifiers = append(identifiers, address.Address)
+	}
+
+	sourceCookbooks, err := Level27Client.SystemCookbookGetList(sourceID, l27.CommonGetParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cookbookType := range systemCookbookCopyTypes {
+		found := false
+		for _, cookbook := range sourceCookbooks {
+			found = found || cookbook.CookbookType == cookbookType
+		}
+
+		if !found {
+			return nil, fmt.Errorf("system %s does not have a cookbook of type '%s'", source.Name, cookbookType)
+		}
+	}
+
+	targetCookbooks, err := Level27Client.SystemCookbookGetList(targetID, l27.CommonGetParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []systemCookbookCopyChange{}
+	for _, cookbook := range sourceCookbooks {
+		if len(systemCookbookCopyTypes) != 0 && !sliceContains(systemCookbookCopyTypes, cookbook.CookbookType) {
+			continue
+		}
+
+		sourceCookbook, err := Level27Client.SystemCookbookDescribe(sourceID, cookbook.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Default values don't need to be copied, the target gets them anyway.
+		parameters := map[string]interface{}{}
+		for key, value := range sourceCookbook.CookbookParameters.Map {
+			if !value.Default {
+				parameters[key] = value.Value
+			}
+		}
+
+		parameters, skipped := utils.SplitSystemSpecificParameters(parameters, identifiers)
+		if len(skipped) != 0 {
+			fmt.Fprintf(os.Stderr, "Not copying parameters of cookbook %s that refer to %s: %s\n", cookbook.CookbookType, source.Name, strings.Join(skipped, ", "))
+		}
+
+		cookbookTypeData, _, err := Level27Client.SystemCookbookTypeGet(cookbook.CookbookType)
+		if err != nil {
+			return nil, err
+		}
+
+		change := systemCookbookCopyChange{
+			Request: l27.CookbookRequest{
+				Cookbooktype:       cookbook.CookbookType,
+				Cookbookparameters: map[string]interface{}{},
+			},
+			Result: systemCookbookCopyResult{CookbookType: cookbook.CookbookType, Action: "add", Skipped: strings.Join(skipped, ", ")},
+		}
+
+		// Cookbook types can't repeat for one system, so an existing cookbook of the same type gets updated instead.
+		for _, targetCookbook := range targetCookbooks {
+			if targetCookbook.CookbookType != cookbook.CookbookType {
+				continue
+			}
+
+			current, err := Level27Client.SystemCookbookDescribe(targetID, targetCookbook.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			// Like lvl system cookbooks update: non-default parameters have to be sent again, or they are reset.
+			for key, value := range current.CookbookParameters.Map {
+				if !value.Default {
+					change.Request.Cookbookparameters[key] = value.Value
+				}
+			}
+
+			change.CookbookID = targetCookbook.ID
+			change.Result.Action = "update"
+		}
+
+		err = checkForValidCookbookParameter(parameters, cookbookTypeData, targetOS, &change.Request)
+		if err != nil {
+			return nil, fmt.Errorf("cookbook %s can't be copied to %s: %s", cookbook.CookbookType, target.Name, err.Error())
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/level27/l27-go"
+	"github.com/level27/lvl/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// SYSTEM COOKBOOKS DIFF
+	systemCookbookCmd.AddCommand(systemCookbookDiffCmd)
+	systemCookbookDiffCmd.Flags().StringVarP(&systemCookbookDiffFile, "file", "f", "", "Compare the system against the cookbooks in this YAML file instead of another system")
+}
+
+// SYSTEM COOKBOOKS DIFF
+var systemCookbookDiffFile string
+var systemCookbookDiffCmd = &cobra.Command{
+	Use:   "diff <system> (<other system> | -f <cookbooks.yaml>)",
+	Short: "Compare the cookbooks of two systems, or of a system against a file",
+	Long: `Compare the cookbooks of two systems, or of a system against a file.
+Parameters at the default value of their cookbook type are the same as parameters that are not set.
+The file lists cookbooks by type, with their parameters:
+
+  cookbooks:
+    php:
+      versions: ["8.1", "8.2"]
+    nginx: {}
+
+Exits with status 1 when there are differences, so it can be used in compliance checks.`,
+	Example: `lvl system cookbooks diff web1 web2
+lvl system cookbooks diff web1 -f webserver-cookbooks.yaml`,
+
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if systemCookbookDiffFile == "" && len(args) != 2 {
+			return fmt.Errorf("another system or a file (-f) to compare to is required")
+		}
+
+		if systemCookbookDiffFile != "" && len(args) != 1 {
+			return fmt.Errorf("can't compare to another system and a file (-f) at the same time")
+		}
+
+		nameA := args[0]
+		setA, err := getSystemCookbookSet(args[0])
+		if err != nil {
+			return err
+		}
+
+		var nameB string
+		var setB utils.CookbookSet
+		if systemCookbookDiffFile != "" {
+			nameB = systemCookbookDiffFile
+			setB, err = utils.LoadCookbookSet(systemCookbookDiffFile)
+		} else {
+			nameB = args[1]
+			setB, err = getSystemCookbookSet(args[1])
+		}
+
+		if err != nil {
+			return err
+		}
+
+		defaults, err := getCookbookTypeDefaults(setA, setB)
+		if err != nil {
+			return err
+		}
+
+		diffs := utils.DiffCookbooks(setA, setB, defaults)
+		outputFormatTable(
+			diffs,
+			[]string{"COOKBOOKTYPE", "PARAMETER", nameA, nameB},
+			[]string{"CookbookType", "Parameter", "A", "B"})
+
+		if len(diffs) != 0 {
+			fmt.Fprintf(os.Stderr, "\n%d differences between %s and %s\n", len(diffs), nameA, nameB)
+			return errSilent
+		}
+
+		fmt.Fprintf(os.Stderr, "No differences between %s and %s\n", nameA, nameB)
+		return nil
+	},
+}
+
+// Get the cookbooks of a system with their non-default parameters.
+func getSystemCookbookSet(arg string) (utils.CookbookSet, error) {
+	systemID, err := resolveSystem(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	cookbooks, err := Level27Client.SystemCookbookGetList(systemID, l27.CommonGetParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []<-chan resultPair[l27.Cookbook]{}
+	for _, cookbook := range cookbooks {
+		cookbook := cookbook
+		tasks = append(tasks, taskRun(func() (l27.Cookbook, error) {
+			return Level27Client.SystemCookbookDescribe(systemID, cookbook.ID)
+		}))
+	}
+
+	set := utils.CookbookSet{}
+	for _, task := range tasks {
+		result := <-task
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		parameters := map[string]interface{}{}
+		for key, value := range result.Result.CookbookParameters.Map {
+			if !value.Default {
+				parameters[key] = value.Value
+			}
+		}
+
+		set[result.Result.CookbookType] = parameters
+	}
+
+	return set, nil
+}
+
+// Get the default parameter values of all cookbook types in the given sets, by cookbook type and parameter.
+// This also makes sure every cookbook type and parameter exists, which matters for sets loaded from a file.
+func getCookbookTypeDefaults(sets ...utils.CookbookSet) (map[string]map[string]string, error) {
+	defaults := map[string]map[string]string{}
+	for _, set := range sets {
+		for cookbookType, parameters := range set {
+			if _, ok := defaults[cookbookType]; !ok {
+				cookbookTypeData, _, err := Level27Client.SystemCookbookTypeGet(cookbookType)
+				if err != nil {
+					return nil, fmt.Errorf("invalid cookbooktype '%s': %s", cookbookType, err.Error())
+				}
+
+				defaults[cookbookType] = map[string]string{}
+				for _, parameter := range cookbookTypeData.CookbookType.Parameters {
+					defaults[cookbookType][parameter.Name] = utils.CheckParameterString(parameter.DefaultValue)
+				}
+			}
+
+			for name := range parameters {
+				if _, ok := defaults[cookbookType][name]; !ok {
+					return nil, fmt.Errorf("given parameter key: '%v' NOT valid for cookbooktype %v", name, cookbookType)
+				}
+			}
+		}
+	}
+
+	return defaults, nil
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/sync/errgroup"
+)
+
+func init() {
+	// SYSTEM EXEC
+	systemCmd.AddCommand(systemExecCmd)
+	flags := systemExecCmd.Flags()
+	flags.StringVar(&systemExecGroup, "group", "", "Run the command on all systems in this systemgroup")
+	flags.StringSliceVar(&systemExecSystems, "systems", nil, "Run the command on these systems (e.g. web1,web2)")
+	flags.IntVar(&systemExecParallel, "parallel", 10, "Maximum amount of systems to run the command on at the same time")
+	addSshFlags(systemExecCmd)
+	addSshKeyFlag(systemExecCmd)
+}
+
+// Result of running a command on a single system.
+type systemExecResult struct {
+	System   string `json:"system"`
+	Host     string `json:"host"`
+	ExitCode int    `json:"exitCode"`
+	Error    string `json:"error,omitempty"`
+	// Output is only captured with JSON output, otherwise it is written directly.
+	Stdout string `json:"stdout,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// SYSTEM EXEC
+var systemExecGroup string
+var systemExecSystems []string
+var systemExecParallel int
+var systemExecCmd = &cobra.Command{
+	Use:   "exec (--group <systemgroup> | --systems <system,...>) [flags] -- <command>",
+	Short: "Run a command on multiple systems via SSH",
+	Long: `Run a command on multiple systems via SSH.
+Your favorite SSH key (or the one passed with --key) is added to every system if necessary. Users and jump hosts are handled like with 'lvl system ssh'.
+The command runs on all systems at the same time (limited by --parallel), every line of output is prefixed with the system name.
+Afterwards the exit code of every system is listed. The command exits with a non-zero status if it failed on any system.
+With -o json, output is captured and included in the JSON result instead.`,
+	Example: `lvl system exec --group webservers -- uptime
+lvl system exec --systems web1,web2 --parallel 1 -- systemctl restart nginx
+lvl system exec --group webservers -o json -- cat /etc/debian_version`,
+
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sshKeyID, err := getSshKeyID()
+		if err != nil {
+			return err
+		}
+
+		if systemExecParallel < 1 {
+			return fmt.Errorf("--parallel must be at least 1")
+		}
+
+		systems, err := resolveSystemSelection(systemExecGroup, systemExecSystems)
+		if err != nil {
+			return err
+		}
+
+		// Make sure the SSH key is on every system and resolve their addresses, before running anything.
+		// Systems that fail to prepare are skipped, and listed with the results.
+		targets := make([]sshTarget, len(systems))
+		prepareErrors := make([]error, len(systems))
+		var prepareGroup errgroup.Group
+		for i, system := range systems {
+			i, system := i, system
+			prepareGroup.Go(func() error {
+				targets[i], prepareErrors[i] = prepareSshTarget(system.ID, sshKeyID, false)
+				return nil
+			})
+		}
+
+		prepareGroup.Wait()
+
+		ready := []sshTarget{}
+		for i, target := range targets {
+			if prepareErrors[i] == nil {
+				ready = append(ready, target)
+			}
+		}
+
+		capture := viper.GetString("output") == "json"
+		readyResults := runSystemExec(ready, args, systemExecParallel, capture)
+
+		results := []systemExecResult{}
+		for i, system := range systems {
+			if prepareErrors[i] != nil {
+				results = append(results, systemExecResult{System: system.Name, ExitCode: -1, Error: prepareErrors[i].Error()})
+				continue
+			}
+
+			results = append(results, readyResults[0])
+			readyResults = readyResults[1:]
+		}
+
+		outputFormatTable(
+			results,
+			[]string{"SYSTEM", "HOST", "EXIT CODE", "ERROR"},
+			[]string{"System", "Host", "ExitCode", "Error"})
+
+		failed := 0
+		for _, result := range results {
+			if result.ExitCode != 0 {
+				failed += 1
+			}
+		}
+
+		if failed != 0 {
+			return fmt.Errorf("command failed on %d of %d systems", failed, len(results))
+		}
+
+		return nil
+	},
+}
+
+// Run a command on systems over SSH, at most parallel at a time.
+// If capture is set, output is stored in the results. Otherwise it is written to stdout/stderr, prefixed with the system name.
+func runSystemExec(targets []sshTarget, command []string, parallel int, capture bool) []systemExecResult {
+	results := make([]systemExecResult, len(targets))
+
+	// Pad prefixes so output of different systems lines up.
+	nameWidth := 0
+	for _, target := range targets {
+		if len(target.System.Name) > nameWidth {
+			nameWidth = len(target.System.Name)
+		}
+	}
+
+	var outputLock sync.Mutex
+	var group errgroup.Group
+	group.SetLimit(parallel)
+	for i, target := range targets {
+		i, target := i, target
+		group.Go(func() error {
+			result := systemExecResult{System: target.System.Name, Host: target.Host}
+
+			sshArgs := append([]string{"-o", "BatchMode=yes"}, target.Options()...)
+			sshArgs = append(sshArgs, target.Destination(), "--")
+			sshArgs = append(sshArgs, command...)
+			sshCmd := exec.Command("ssh", sshArgs...)
+
+			var stdout, stderr bytes.Buffer
+			var stdoutPrefix, stderrPrefix *prefixWriter
+			if capture {
+				sshCmd.Stdout = &stdout
+				sshCmd.Stderr = &stderr
+			} else {
+				prefix := fmt.Sprintf("%-*s | ", nameWidth, target.System.Name)
+				stdoutPrefix = &prefixWriter{Prefix: prefix, Out: os.Stdout, Lock: &outputLock}
+				stderrPrefix = &prefixWriter{Prefix: prefix, Out: os.Stderr, Lock: &outputLock}
+				sshCmd.Stdout = stdoutPrefix
+				sshCmd.Stderr = stderrPrefix
+			}
+
+			err := sshCmd.Run()
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				result.ExitCode = exitErr.ExitCode()
+			} else if err != nil {
+				result.ExitCode = -1
+				result.Error = err.Error()
+			}
+
+			if capture {
+				result.Stdout = stdout.String()
+				result.Stderr = stderr.String()
+			} else {
+				stdoutPrefix.Flush()
+				stderrPrefix.Flush()
+			}
+
+			// ssh uses exit code 255 for its own errors, e.g. failing to connect.
+			if result.ExitCode == 255 && result.Error == "" {
+				result.Error = "ssh failed, the system may be unreachable"
+			}
+
+			results[i] = result
+			return nil
+		})
+	}
+
+	group.Wait()
+	return results
+}
+
+// Writer that prefixes every line written to it, for interleaving output of multiple processes.
+// Only complete lines are written to Out, with Lock held so lines of different writers don't mix.
+type prefixWriter struct {
+	Prefix string
+	Out    io.Writer
+	Lock   *sync.Mutex
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(data []byte) (int, error) {
+	w.buf = append(w.buf, data...)
+
+	idx := bytes.LastIndexByte(w.buf, '\n')
+	if idx == -1 {
+		return len(data), nil
+	}
+
+	lines := strings.SplitAfter(string(w.buf[:idx+1]), "\n")
+	w.buf = append([]byte{}, w.buf[idx+1:]...)
+
+	w.Lock.Lock()
+	defer w.Lock.Unlock()
+
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		if _, err := io.WriteString(w.Out, w.Prefix+line); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(data), nil
+}
+
+// Write out the last line, if it was not terminated by a newline.
+func (w *prefixWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+
+	w.Write([]byte{'\n'})
+}
@@ -66,18 +66,7 @@ var systemNetworkGetCmd = &cobra.Command{
 			return err
 		}
 
-		outputFormatTableFuncs(system.Networks, []string{"ID", "Network ID", "Type", "Name", "MAC", "IPs"}, []interface{}{"ID", "NetworkID", func(net l27.SystemNetwork) string {
-			if net.NetPublic {
-				return "public"
-			}
-			if net.NetCustomer {
-				return "customer"
-			}
-			if net.NetInternal {
-				return "internal"
-			}
-			return ""
-		}, "Name", "Mac", func(net l27.SystemNetwork) string {
+		outputFormatTableFuncs(system.Networks, []string{"ID", "Network ID", "Type", "Name", "MAC", "IPs"}, []interface{}{"ID", "NetworkID", systemNetworkType, "Name", "Mac", func(net l27.SystemNetwork) string {
 			return strconv.Itoa(len(net.Ips))
 		}})
 
@@ -167,6 +156,20 @@ var systemNetworkRemoveCmd = &cobra.Command{
 	},
 }
 
+// Get the type of a system network: public, customer or internal.
+func systemNetworkType(net l27.SystemNetwork) string {
+	if net.NetPublic {
+		return "public"
+	}
+	if net.NetCustomer {
+		return "customer"
+	}
+	if net.NetInternal {
+		return "internal"
+	}
+	return ""
+}
+
 type DescribeSystemNetworks struct {
 	Networks    []l27.SystemNetwork    `json:"networks"`
 	HasNetworks []l27.SystemHasNetwork `json:"hasNetworks"`
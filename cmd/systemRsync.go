@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// SYSTEM RSYNC
+	systemCmd.AddCommand(systemRsyncCmd)
+	addSshFlags(systemRsyncCmd)
+	addSshKeyFlag(systemRsyncCmd)
+
+	// SYSTEM SFTP
+	systemCmd.AddCommand(systemSftpCmd)
+	addSshFlags(systemSftpCmd)
+	addSshKeyFlag(systemSftpCmd)
+}
+
+// SYSTEM RSYNC
+var systemRsyncCmd = &cobra.Command{
+	Use:   "rsync [flags] [--] [rsync args] [[user@]system:]src... [[user@]system:]dest",
+	Short: "Copy files to/from a system using rsync",
+	Long: `Copy files to/from a system using rsync.
+Works like 'lvl system scp': arguments are passed through to rsync, but host names (before the :) are interpreted as system names/IDs and resolved.
+Users, keys and jump hosts are handled like with 'lvl system ssh', the ssh options needed for them are passed to rsync with -e.
+To pass flags through to rsync, put them after a --. rsync can only copy between your machine and a single system.`,
+	Example: `lvl system rsync -- -avz ./site/ my-web-server:/var/www/site/
+lvl system rsync --jump my-bastion -- -av my-internal-server:/var/log/app/ ./logs/`,
+
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sshKeyID, err := getSshKeyID()
+		if err != nil {
+			return err
+		}
+
+		remoteArgs, options, targets, err := prepareRemoteFileArgs(args, sshKeyID)
+		if err != nil {
+			return err
+		}
+
+		if len(targets) > 1 {
+			return fmt.Errorf("rsync can only copy between your machine and a single system")
+		}
+
+		rsyncArgs := []string{}
+		if len(options) != 0 {
+			rsyncArgs = append(rsyncArgs, "-e", rsyncSshCommand(options))
+		}
+
+		rsyncArgs = append(rsyncArgs, remoteArgs...)
+		return tailExecProcess("rsync", rsyncArgs)
+	},
+}
+
+// Get the command to pass to rsync -e to run ssh with options.
+// rsync splits this on spaces itself, and supports single and double quotes but no escapes.
+func rsyncSshCommand(options []string) string {
+	parts := []string{"ssh"}
+	for _, option := range options {
+		if !strings.ContainsAny(option, " '\"") {
+			parts = append(parts, option)
+		} else if !strings.Contains(option, "'") {
+			parts = append(parts, "'"+option+"'")
+		} else {
+			parts = append(parts, `"`+option+`"`)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// SYSTEM SFTP
+var systemSftpCmd = &cobra.Command{
+	Use:   "sftp [user@]<system>[:path] [flags] [--] [sftp args]",
+	Short: "Connect to a system using sftp",
+	Long: `Connect to a system using sftp.
+Users, keys and jump hosts are handled like with 'lvl system ssh'.
+Arguments passed after the system are passed to sftp. To pass flags through to sftp, put them after a --.`,
+	Example: `lvl system sftp my-web-server
+lvl system sftp deploy@my-web-server:/var/www
+lvl system sftp my-web-server -- -b batch.txt`,
+
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sshKeyID, err := getSshKeyID()
+		if err != nil {
+			return err
+		}
+
+		remote := args[0]
+		if !strings.Contains(remote, ":") {
+			remote += ":"
+		}
+
+		remoteArgs, options, _, err := prepareRemoteFileArgs([]string{remote}, sshKeyID)
+		if err != nil {
+			return err
+		}
+
+		// sftp takes its flags before the destination.
+		sftpArgs := append(options, args[1:]...)
+		sftpArgs = append(sftpArgs, strings.TrimSuffix(remoteArgs[0], ":"))
+		return tailExecProcess("sftp", sftpArgs)
+	},
+}
@@ -7,7 +7,6 @@ import (
 	"os"
 	"path"
 	"regexp"
-	"sort"
 	"strings"
 
 	"github.com/kevinburke/ssh_config"
@@ -15,7 +14,6 @@ import (
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
-	"golang.org/x/sync/errgroup"
 )
 
 // Contains commands like lvl system ssh.
@@ -23,62 +21,94 @@ import (
 func init() {
 	// SYSTEM SSH
 	systemCmd.AddCommand(systemSshCmd)
+	addSshFlags(systemSshCmd)
+	addSshKeyFlag(systemSshCmd)
+	systemSshCmd.Flags().BoolVar(&systemSshEphemeral, "ephemeral", false, "Connect with a temporary SSH key, which is removed from the system and your account afterwards")
 
 	// SYSTEM SCP
 	systemCmd.AddCommand(systemScpCommand)
+	addSshFlags(systemScpCommand)
+	addSshKeyFlag(systemScpCommand)
 
 	// SYSTEM SSHCONFIG
 	systemCmd.AddCommand(systemSshConfigCmd)
+	addSshFlags(systemSshConfigCmd)
+	flags := systemSshConfigCmd.Flags()
+	flags.BoolVar(&systemSshConfigAll, "all", false, "Write entries for all systems")
+	flags.StringVar(&systemSshConfigGroup, "group", "", "Write entries for all systems in this systemgroup")
+	flags.BoolVar(&systemSshConfigPrune, "prune", false, "Remove entries of systems that no longer exist, with --all or --group")
+	flags.BoolVar(&systemSshConfigOrgAlias, "org-alias", false, "Also add an alias prefixed with the organisation name, like acme-web1")
 }
 
 // SYSTEM SSH
+var systemSshEphemeral bool
 var systemSshCmd = &cobra.Command{
 	Use:   "ssh <system> [flags] [--] [ssh args]",
 	Short: "Connect to a system via SSH, automatically adding SSH keys to the system if necessary",
 	Long: `Connect to a system via SSH, automatically adding SSH keys to the system if necessary.
-The command will automatically add your favorite SSH key to the system if necessary. You'll need to use 'lvl sshkey favorite' to configure it the first time.
+The command will automatically add your favorite SSH key to the system if necessary. You'll need to use 'lvl sshkey favorite' to configure it the first time, or pick a key with --key.
+You are logged in as root, unless another user is passed with --user or configured for the system in the config file:
+  ssh_users:
+    my-app-server: deploy
 The command figures out a valid IP address to connect to and passes it through to the ssh command.
-Arguments passed after the system ID/name are passed to ssh literally. Note that for any flags starting with "-", you'll want to put "--" before them so lvl does not try to interpret them as flags itself.`,
+Use --ipv6, --internal or --network to pick another address of the system, 'lvl system address' lists them.
+Arguments passed after the system ID/name are passed to ssh literally. Note that for any flags starting with "-", you'll want to put "--" before them so lvl does not try to interpret them as flags itself.
+
+Systems that are only reachable through a jump host (bastion) can be connected to with --jump, or by configuring a jump host in the config file.
+The SSH key is then added to both systems, and the system's internal address is connected to through the jump host:
+  ssh_jump: my-bastion          # For all systems
+  ssh_jump_groups:              # Per systemgroup, takes priority over ssh_jump
+    customer-a: customer-a-bastion
+
+With --ephemeral, a new SSH key is generated and added to your account and the system just for this session, instead of using your favorite key.
+When the session ends (or is interrupted), the key is removed from the system and your account again.`,
 	Example: `lvl system ssh my-awesome-server
 lvl system ssh my-awesome-server ls "~"
-lvl system ssh my-awesome-server -- ls -l "~"`,
+lvl system ssh my-awesome-server -- ls -l "~"
+lvl system ssh my-internal-server --jump my-bastion
+lvl system ssh my-awesome-server --user deploy --key deploy-key --identity-file ~/.ssh/deploy
+lvl system ssh my-awesome-server --ephemeral`,
 
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		favoriteKeyID := viper.GetInt32("ssh_favoritekey")
-		if favoriteKeyID == 0 {
-			return fmt.Errorf("no favorite SSH key configured. Use 'lvl sshkey favorite' to configure one")
+		if systemSshEphemeral {
+			if optSshKey != "" || optSshIdentityFile != "" {
+				return fmt.Errorf("--ephemeral can't be combined with --key or --identity-file")
+			}
+
+			systemID, err := resolveSystem(args[0])
+			if err != nil {
+				return err
+			}
+
+			exitCode, err := runEphemeralSsh(systemID, args[1:])
+			if err != nil {
+				return err
+			}
+
+			if exitCode != 0 {
+				os.Exit(exitCode)
+			}
+
+			return nil
 		}
 
-		systemID, err := resolveSystem(args[0])
+		sshKeyID, err := getSshKeyID()
 		if err != nil {
 			return err
 		}
 
-		// We need to do two things:
-		// 1. Make sure we have an SSH key on the system.
-		// 2. Fetch the host to pass in the ssh command.
-		// We send these as concurrent tasks to reduce latency on the command.
-
-		taskSshKey := taskRunVoid(func() error {
-			return waitEnsureSshKey(systemID, favoriteKeyID)
-		})
-
-		taskSshHost := taskRun(func() (string, error) {
-			return sshResolveHost(systemID, false)
-		})
-
-		sshHost := <-taskSshHost
-		if sshHost.Error != nil {
-			return sshHost.Error
+		systemID, err := resolveSystem(args[0])
+		if err != nil {
+			return err
 		}
 
-		err = <-taskSshKey
+		target, err := prepareSshTarget(systemID, sshKeyID, false)
 		if err != nil {
 			return err
 		}
 
-		sshArgs := []string{fmt.Sprintf("root@%s", sshHost.Result)}
+		sshArgs := append(target.Options(), target.Destination())
 		sshArgs = append(sshArgs, args[1:]...)
 
 		return tailExecProcess("ssh", sshArgs)
@@ -135,12 +165,7 @@ func waitAddSshKey(systemID l27.IntID, sshKeyID l27.IntID) error {
 // Resolve the hostname to SSH into a system.
 // If the FQDN properly resolves, we use that.
 // Otherwise we try the IP addresses in the system's networks.
-func sshResolveHost(systemID l27.IntID, preferIP bool) (string, error) {
-	system, err := Level27Client.SystemGetSingle(systemID)
-	if err != nil {
-		return "", err
-	}
-
+func sshResolveSystemHost(system l27.System, preferIP bool) (string, error) {
 	ips, err := net.LookupIP(system.Fqdn)
 	if err == nil && len(ips) > 0 {
 		// FQDN resolves, pass it to the ssh command.
@@ -151,21 +176,12 @@ func sshResolveHost(systemID l27.IntID, preferIP bool) (string, error) {
 		return system.Fqdn, nil
 	}
 
-	sort.Slice(system.Networks, func(i int, j int) bool {
-		netA := system.Networks[i]
-		netB := system.Networks[j]
-
-		return netA.NetPublic && netB.NetInternal
-	})
-
-	for _, net := range system.Networks {
-		for _, ip := range net.Ips {
-			if ip.PublicIpv4 != "" {
-				return ip.PublicIpv4, nil
-			}
-
-			if ip.Ipv4 != "" {
-				return ip.Ipv4, nil
+	// Public networks come first, IPv6 is only used if the system has no IPv4 address at all.
+	addresses := getSystemAddresses(system)
+	for _, family := range []string{"IPv4", "IPv6"} {
+		for _, address := range addresses {
+			if address.Family == family {
+				return address.Address, nil
 			}
 		}
 	}
@@ -176,192 +192,269 @@ func sshResolveHost(systemID l27.IntID, preferIP bool) (string, error) {
 
 // SYSTEM SCP
 var systemScpCommand = &cobra.Command{
-	Use:     "scp [system1:]file1 ... [system2:]file2",
-	Short:   "Copy files to/from the system using scp",
-	Long:    "Uses the same syntax as regular scp. Arguments are passed through, but host names (before the :) are interpreted as system names/IDs and resolved. To pass flags through to scp, put them after a --",
-	Example: "lvl system scp foo.txt mySystem:~/foo.txt",
+	Use:   "scp [system1:]file1 ... [system2:]file2",
+	Short: "Copy files to/from the system using scp",
+	Long:  "Uses the same syntax as regular scp. Arguments are passed through, but host names (before the :) are interpreted as system names/IDs and resolved. To pass flags through to scp, put them after a --\nUsers, keys and jump hosts are handled like with 'lvl system ssh'. A user can also be given per file (user@system:file). All remote systems must use the same jump host.",
+	Example: `lvl system scp foo.txt mySystem:~/foo.txt
+lvl system scp deploy@mySystem:~/app.log .`,
 
 	Args: cobra.MinimumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		favoriteKeyID := viper.GetInt32("ssh_favoritekey")
-		if favoriteKeyID == 0 {
-			return fmt.Errorf("no favorite SSH key configured. Use 'lvl sshkey favorite' to configure one")
-		}
-
-		// This code is quite complex to be able to be as optimally concurrent (and fast) as possible.
-		// Basically how it works:
-		// For each argument, we need to resolve the system if it's a system:file argument
-		// These resolves are done concurrently. They send back the new value of the arg when they're done.
-		// We also need to make sure SSH keys are added, this goes via another set of channels to also be concurrent.
-
-		// Goroutine to asynchronously add SSH keys to systems while we go through resolving systems down below.
-		// We need this to avoid trying to add an SSH key to the same system twice, causing race conditions.
-		keyAddChannel := make(chan l27.IntID)
-		keyDone := taskRunVoid(func() error {
-			var group errgroup.Group
-			// Map of systems we're already handling SSH keys on, to avoid running them twice.
-			systemsEnsured := map[l27.IntID]bool{}
-			for systemID := range keyAddChannel {
-				sysID := systemID
-				if _, ok := systemsEnsured[systemID]; ok {
-					continue
-				}
-
-				systemsEnsured[systemID] = true
-				group.Go(func() error { return waitEnsureSshKey(sysID, favoriteKeyID) })
-			}
-
-			return group.Wait()
-		})
-
-		var systemArgsGroup errgroup.Group
-		systemArgsChannel := make(chan tuple2[int, string])
-
-		// Copy input arguments to pass them through to scp.
-		// We will modify the ones that are remote files to replace system hosts with the real IP/domain
-		scpArgs := append([]string{}, args...)
-
-		argTask := taskRunVoid(func() error {
-			// Go over remote arguments, and resolve the system.
-			for i, arg := range args {
-				split := strings.SplitN(arg, ":", 2)
-				if len(split) == 1 {
-					// No host specified, so local file or flag or something.
-					// TODO: this means of parsing mostly works, but it means that any flag parameters with a colon in them
-					// will be interpreted as a remote file.
-					// It might be a good idea to manually pass-through flag args for well-known flags.
-					continue
-				}
-
-				// Do this all in parallel with goroutines to avoid chaining latency, nice and fast.
-				ii := i
-				systemArgsGroup.Go(func() error {
-					system := split[0]
-					file := split[1]
-
-					systemID, err := resolveSystem(system)
-					if err != nil {
-						return err
-					}
-
-					// Send ID to SSH key channel so the SSH key gets added.
-					keyAddChannel <- systemID
-
-					host, err := sshResolveHost(systemID, false)
-					if err != nil {
-						return err
-					}
-
-					// Send arg index and new value so the value gets replaced.
-					systemArgsChannel <- makeTuple2(ii, fmt.Sprintf("root@%s:%s", host, file))
-					return nil
-				})
-			}
-
-			// Wait for all systems to finish resolving.
-			err := systemArgsGroup.Wait()
-			close(systemArgsChannel)
+		sshKeyID, err := getSshKeyID()
+		if err != nil {
 			return err
-		})
-
-		// Update all the args that need updating from the above loop.
-		for tuple := range systemArgsChannel {
-			scpArgs[tuple.Item1] = tuple.Item2
 		}
 
-		// Handle errors from argument processing.
-		if err := <-argTask; err != nil {
+		remoteArgs, options, _, err := prepareRemoteFileArgs(args, sshKeyID)
+		if err != nil {
 			return err
 		}
 
-		// All args have been processed, so also all SSH keys have been dispatched at least.
-		close(keyAddChannel)
+		scpArgs := append(options, remoteArgs...)
+		return tailExecProcess("scp", scpArgs)
+	},
+}
+
+// Resolve the remote files in arguments for scp-like commands ([user@]system:file), see lvl system scp.
+// Every system is prepared like with lvl system ssh, and replaced with its address in the arguments.
+// Returns the arguments, the ssh options to connect with and the systems that were prepared.
+func prepareRemoteFileArgs(args []string, sshKeyID l27.IntID) ([]string, []string, []sshTarget, error) {
+	// The system may be prefixed with a user, like with regular scp.
+	type remoteFile struct {
+		User     string
+		SystemID l27.IntID
+		Path     string
+	}
+
+	remoteFiles := map[int]remoteFile{}
+	systemIDs := []l27.IntID{}
+	for i, arg := range args {
+		split := strings.SplitN(arg, ":", 2)
+		if len(split) == 1 {
+			// No host specified, so local file or flag or something.
+			// TODO: this means of parsing mostly works, but it means that any flag parameters with a colon in them
+			// will be interpreted as a remote file.
+			// It might be a good idea to manually pass-through flag args for well-known flags.
+			continue
+		}
+
+		user, systemName := "", split[0]
+		if idx := strings.LastIndex(systemName, "@"); idx != -1 {
+			user, systemName = systemName[:idx], systemName[idx+1:]
+		}
 
-		// Wait for all SSH keys to be available on systems.
-		err := <-keyDone
+		systemID, err := resolveSystem(systemName)
 		if err != nil {
-			return err
+			return nil, nil, nil, err
 		}
 
-		return tailExecProcess("scp", scpArgs)
-	},
+		remoteFiles[i] = remoteFile{User: user, SystemID: systemID, Path: split[1]}
+		if indexOf(systemIDs, systemID) == -1 {
+			systemIDs = append(systemIDs, systemID)
+		}
+	}
+
+	// Every system is prepared (SSH key added, address resolved) once, concurrently.
+	// Preparing the same system twice would try to add the same SSH key twice, causing race conditions.
+	// This is why systems are resolved to their ID first, the same system can be given by name and by ID, or with different users.
+	targetTasks := []<-chan resultPair[sshTarget]{}
+	for _, systemID := range systemIDs {
+		systemID := systemID
+		targetTasks = append(targetTasks, taskRun(func() (sshTarget, error) {
+			return prepareSshTarget(systemID, sshKeyID, false)
+		}))
+	}
+
+	// Wait for all systems, even if one fails, so we don't exit while keys are still being added.
+	var err error
+	targets := map[l27.IntID]sshTarget{}
+	prepared := []sshTarget{}
+	for i, task := range targetTasks {
+		result := <-task
+		if result.Error != nil {
+			err = result.Error
+		}
+
+		targets[systemIDs[i]] = result.Result
+		prepared = append(prepared, result.Result)
+	}
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// ssh options apply to all remote files, so they can only use one jump host.
+	options := []string{}
+	for i, target := range prepared {
+		if i != 0 && target.Jump != prepared[0].Jump {
+			return nil, nil, nil, fmt.Errorf("systems '%s' and '%s' use different jump hosts, copy to them separately", prepared[0].System.Name, target.System.Name)
+		}
+
+		options = target.Options()
+	}
+
+	// Copy input arguments to pass them through.
+	// The remote files get the system replaced with the real IP/domain.
+	newArgs := []string{}
+	for i, arg := range args {
+		if remote, ok := remoteFiles[i]; ok {
+			target := targets[remote.SystemID]
+			if remote.User != "" {
+				target.User = remote.User
+			}
+
+			arg = fmt.Sprintf("%s:%s", target.ScpDestination(), remote.Path)
+		}
+
+		newArgs = append(newArgs, arg)
+	}
+
+	return newArgs, options, prepared, nil
 }
 
+// SYSTEM SSHCONFIG
+var systemSshConfigAll bool
+var systemSshConfigGroup string
+var systemSshConfigPrune bool
+var systemSshConfigOrgAlias bool
 var systemSshConfigCmd = &cobra.Command{
-	Use:   "sshconfig",
+	Use:   "sshconfig [system]",
 	Short: "Add system's name to your user SSH config for easy access",
 	Long: `Add system's name to your user SSH config for easy access
 This will add a Host entry to your SSH config, so afterwards you can use commands outside lvl to access the system by name.
 For example: rsync foo.txt my-awesome-system:~/
 
-The new host names are written into a separate ~/.ssh/lvl config file, which gets added to your ~/.ssh/config via an Include directive.`,
+The new host names are written into a separate ~/.ssh/lvl config file, which gets added to your ~/.ssh/config via an Include directive.
+If a jump host is configured for the system (see 'lvl system ssh --help'), a ProxyJump is added to the entry. Make sure your SSH key is on both systems.
 
-	Args: cobra.ExactArgs(1),
+With --all or --group, entries are written for all (or all the group's) systems at once, and the added/updated/removed hosts are listed.
+With --prune, entries of systems that no longer exist or were renamed are removed.
+With --org-alias, every entry can also be accessed as <organisation>-<system>.`,
+	Example: `lvl system sshconfig my-awesome-system
+lvl system sshconfig --all --prune
+lvl system sshconfig --group webservers --org-alias`,
+
+	Args: func(cmd *cobra.Command, args []string) error {
+		if systemSshConfigAll || systemSshConfigGroup != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if systemSshConfigAll || systemSshConfigGroup != "" {
+			return systemSshConfigSync()
+		}
+
+		if systemSshConfigPrune {
+			return fmt.Errorf("--prune can only be used with --all or --group")
+		}
+
 		systemID, err := resolveSystem(args[0])
 		if err != nil {
 			return err
 		}
 
-		address, err := sshResolveHost(systemID, true)
+		// SSH keys are not added here, the entry is used outside lvl.
+		target, err := prepareSshTarget(systemID, 0, true)
 		if err != nil {
 			return err
 		}
 
-		system, err := Level27Client.SystemGetSingle(systemID)
+		cfg, configPath, err := readSshConfig()
 		if err != nil {
 			return err
 		}
 
-		f, configPath, err := ensureSshConfig()
+		_, err = setSshConfigTarget(cfg, target, sshConfigPatterns(target.System))
 		if err != nil {
 			return err
 		}
 
-		var cfg *ssh_config.Config
-		(func() {
-			defer f.Close()
-
-			cfg, err = ssh_config.Decode(f)
-		})()
-
+		err = writeSshConfig(cfg, configPath)
 		if err != nil {
-			return fmt.Errorf("unable to parse SSH config: %s", err.Error())
+			return err
 		}
 
-		host := findSshConfigHost(cfg, system.Name)
-		if host == nil {
-			pattern, err := ssh_config.NewPattern(system.Name)
-			if err != nil {
-				return fmt.Errorf("invalid SSH config host value: '%s'", system.Name)
-			}
+		outputFormatTemplate(target.System, "templates/entities/system/sshConfigConfirm.tmpl")
 
-			// Host not in config file yet, add a new one!
-			host = &ssh_config.Host{
-				Patterns: []*ssh_config.Pattern{pattern},
-			}
+		return nil
+	},
+}
 
-			cfg.Hosts = append(cfg.Hosts, host)
-		}
+// Read and parse lvl's SSH config file, creating it if necessary.
+func readSshConfig() (*ssh_config.Config, string, error) {
+	f, configPath, err := ensureSshConfig()
+	if err != nil {
+		return nil, "", err
+	}
 
-		setSshConfigHostNode(host, "HostName", address)
-		setSshConfigHostNode(host, "User", "root")
+	defer f.Close()
 
-		// Write new config back to file.
-		err = os.WriteFile(configPath, []byte(cfg.String()), 0o644)
+	cfg, err := ssh_config.Decode(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to parse SSH config: %s", err.Error())
+	}
+
+	return cfg, configPath, nil
+}
+
+// Write lvl's SSH config file, and make sure it is included from ~/.ssh/config.
+func writeSshConfig(cfg *ssh_config.Config, configPath string) error {
+	err := os.WriteFile(configPath, []byte(cfg.String()), 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %s", configPath, err.Error())
+	}
+
+	return ensureSshIncludeWritten()
+}
+
+// Add or update the Host entry for a system in an SSH config, with the given patterns (see sshConfigPatterns).
+// The entry is found by its first pattern, the system name. Returns whether the entry was added.
+func setSshConfigTarget(cfg *ssh_config.Config, target sshTarget, patterns []string) (bool, error) {
+	newPatterns := []*ssh_config.Pattern{}
+	for _, value := range patterns {
+		pattern, err := ssh_config.NewPattern(value)
 		if err != nil {
-			return fmt.Errorf("failed to write %s: %s", configPath, err.Error())
+			return false, fmt.Errorf("invalid SSH config host value: '%s'", value)
 		}
 
-		err = ensureSshIncludeWritten()
-		if err != nil {
-			return err
+		newPatterns = append(newPatterns, pattern)
+	}
+
+	added := false
+	host := findSshConfigHost(cfg, patterns[0])
+	if host == nil {
+		// Host not in config file yet, add a new one!
+		host = &ssh_config.Host{}
+		cfg.Hosts = append(cfg.Hosts, host)
+		added = true
+	}
+
+	host.Patterns = newPatterns
+
+	setSshConfigHostNode(host, "HostName", target.Host)
+	setSshConfigHostNode(host, "User", target.User)
+	if target.IdentityFile != "" {
+		setSshConfigHostNode(host, "IdentityFile", target.IdentityFile)
+	} else {
+		// Drop a key from an earlier run with --identity-file.
+		removeSshConfigHostNode(host, "IdentityFile")
+	}
+
+	// Drop a jump host from an earlier run that no longer applies.
+	proxyKey, proxyValue := target.ProxyOption()
+	for _, key := range []string{"ProxyJump", "ProxyCommand"} {
+		if key != proxyKey {
+			removeSshConfigHostNode(host, key)
 		}
+	}
 
-		outputFormatTemplate(system, "templates/entities/system/sshConfigConfirm.tmpl")
+	if proxyKey != "" {
+		setSshConfigHostNode(host, proxyKey, proxyValue)
+	}
 
-		return nil
-	},
+	return added, nil
 }
 
 func findSshConfigHost(cfg *ssh_config.Config, name string) *ssh_config.Host {
@@ -396,6 +489,19 @@ func setSshConfigHostNode(host *ssh_config.Host, key string, value string) {
 	})
 }
 
+func removeSshConfigHostNode(host *ssh_config.Host, key string) {
+	nodes := []ssh_config.Node{}
+	for _, node := range host.Nodes {
+		if kv, ok := node.(*ssh_config.KV); ok && kv.Key == key {
+			continue
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	host.Nodes = nodes
+}
+
 func ensureSshConfig() (*os.File, string, error) {
 	return ensureSshDirFileExists(getSshConfigFileName())
 }
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kevinburke/ssh_config"
+	"github.com/level27/l27-go"
+	"golang.org/x/sync/errgroup"
+)
+
+// Change made to lvl's SSH config by lvl system sshconfig --all.
+type sshConfigChange struct {
+	Host   string `json:"host"`
+	Action string `json:"action"`
+}
+
+// Characters that can't be used in an organisation alias.
+var sshConfigAliasInvalidRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Get the Host patterns to write for a system: its name, and the organisation alias with --org-alias.
+func sshConfigPatterns(system l27.System) []string {
+	patterns := []string{system.Name}
+	if !systemSshConfigOrgAlias {
+		return patterns
+	}
+
+	org := sshConfigAliasInvalidRegex.ReplaceAllString(strings.ToLower(system.Organisation.Name), "-")
+	org = strings.Trim(org, "-")
+	if org != "" {
+		patterns = append(patterns, fmt.Sprintf("%s-%s", org, system.Name))
+	}
+
+	return patterns
+}
+
+// Write SSH config entries for all systems selected with --all or --group.
+func systemSshConfigSync() error {
+	allSystems, err := getAllPages("", Level27Client.SystemGetList)
+	if err != nil {
+		return err
+	}
+
+	systems := allSystems
+	if systemSshConfigGroup != "" {
+		groupID, err := resolveSystemgroup(systemSshConfigGroup)
+		if err != nil {
+			return err
+		}
+
+		systems = filterSystemgroupSystems(allSystems, groupID)
+	}
+
+	// Resolve addresses of all systems, at most 10 at a time.
+	// Systems that can't be resolved are skipped with a warning, they shouldn't stop the rest from being written.
+	targets := make([]*sshTarget, len(systems))
+	var group errgroup.Group
+	group.SetLimit(10)
+	for i, system := range systems {
+		i, system := i, system
+		group.Go(func() error {
+			target, err := prepareSshTarget(system.ID, 0, true)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Skipping %s: %s\n", system.Name, err.Error())
+				return nil
+			}
+
+			targets[i] = &target
+			return nil
+		})
+	}
+
+	group.Wait()
+
+	cfg, configPath, err := readSshConfig()
+	if err != nil {
+		return err
+	}
+
+	changes := []sshConfigChange{}
+	for _, target := range targets {
+		if target == nil {
+			continue
+		}
+
+		var before string
+		if host := findSshConfigHost(cfg, target.System.Name); host != nil {
+			before = host.String()
+		}
+
+		added, err := setSshConfigTarget(cfg, *target, sshConfigPatterns(target.System))
+		if err != nil {
+			return err
+		}
+
+		if added {
+			changes = append(changes, sshConfigChange{Host: target.System.Name, Action: "added"})
+		} else if findSshConfigHost(cfg, target.System.Name).String() != before {
+			changes = append(changes, sshConfigChange{Host: target.System.Name, Action: "updated"})
+		}
+	}
+
+	if systemSshConfigPrune {
+		changes = append(changes, pruneSshConfig(cfg, allSystems)...)
+	}
+
+	if len(changes) != 0 {
+		err = writeSshConfig(cfg, configPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Host < changes[j].Host })
+
+	outputFormatTable(changes, []string{"HOST", "ACTION"}, []string{"Host", "Action"})
+	return nil
+}
+
+// Remove Host entries from an SSH config that don't belong to any of the given systems.
+// Entries with wildcard patterns were not written by lvl, and are left alone.
+func pruneSshConfig(cfg *ssh_config.Config, systems []l27.System) []sshConfigChange {
+	names := map[string]bool{}
+	for _, system := range systems {
+		names[system.Name] = true
+	}
+
+	changes := []sshConfigChange{}
+	hosts := []*ssh_config.Host{}
+	for _, host := range cfg.Hosts {
+		name := ""
+		if len(host.Patterns) != 0 {
+			name = host.Patterns[0].String()
+		}
+
+		if name == "" || strings.ContainsAny(name, "*?!") || names[name] {
+			hosts = append(hosts, host)
+			continue
+		}
+
+		changes = append(changes, sshConfigChange{Host: name, Action: "removed"})
+	}
+
+	cfg.Hosts = hosts
+	return changes
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/level27/l27-go"
+	"github.com/spf13/viper"
+	"golang.org/x/sync/errgroup"
+)
+
+// Connect to a system via SSH with a freshly generated key, for lvl system ssh --ephemeral.
+// The key is registered on the user account and added to the system (and jump host) only for the duration of the session.
+// Returns the exit code of ssh.
+func runEphemeralSsh(systemID l27.IntID, args []string) (int, error) {
+	// Catch Ctrl-C for the whole session, so the key always gets removed again.
+	// While ssh is running, it gets the interrupt itself.
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+
+	dir, err := os.MkdirTemp("", "lvl-ephemeral-")
+	if err != nil {
+		return 0, err
+	}
+
+	defer os.RemoveAll(dir)
+
+	description := fmt.Sprintf("lvl ephemeral key %s", time.Now().Format(time.RFC3339))
+	keyFile := filepath.Join(dir, "id_ed25519")
+	keygen := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", description, "-f", keyFile)
+	keygen.Stderr = os.Stderr
+	if err := keygen.Run(); err != nil {
+		return 0, fmt.Errorf("failed to generate SSH key: %s", err.Error())
+	}
+
+	publicKey, err := os.ReadFile(keyFile + ".pub")
+	if err != nil {
+		return 0, err
+	}
+
+	orgID := viper.GetInt32("org_id")
+	userID := viper.GetInt32("user_id")
+	key, err := Level27Client.OrganisationUserSshKeysCreate(orgID, userID, l27.SshKeyCreate{
+		Content:     strings.TrimSpace(string(publicKey)),
+		Description: description,
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	// From here on the key has to be removed again, whatever happens.
+	defer func() {
+		systems := append([]l27.IntID{systemID}, resolvedSshJumpSystems()...)
+		if err := removeEphemeralSshKey(orgID, userID, key.ID, systems); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to remove ephemeral SSH key '%s' (%d), remove it manually: %s\n", description, key.ID, err.Error())
+		}
+	}()
+
+	target, err := prepareSshTarget(systemID, key.ID, false)
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case <-interrupted:
+		fmt.Fprintln(os.Stderr, "Interrupted")
+		return 130, nil
+	default:
+	}
+
+	target.IdentityFile = keyFile
+	sshArgs := append(target.Options(), "-o", "IdentitiesOnly=yes", target.Destination())
+	sshArgs = append(sshArgs, args...)
+
+	sshCmd := exec.Command("ssh", sshArgs...)
+	sshCmd.Stdin = os.Stdin
+	sshCmd.Stdout = os.Stdout
+	sshCmd.Stderr = os.Stderr
+	err = sshCmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+
+	return 0, err
+}
+
+// Remove an ephemeral SSH key from systems it may have been added to, and then from the user account.
+func removeEphemeralSshKey(orgID l27.IntID, userID l27.IntID, keyID l27.IntID, systems []l27.IntID) error {
+	fmt.Fprint(os.Stderr, "Removing ephemeral SSH key")
+
+	var group errgroup.Group
+	for _, systemID := range systems {
+		systemID := systemID
+		group.Go(func() error {
+			err := Level27Client.SystemRemoveSshKey(systemID, keyID)
+			if errResp, ok := err.(l27.ErrorResponse); ok && (errResp.HTTPCode == 404 || errResp.Code == 404) {
+				// Key never made it onto this system.
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			return waitForDelete(
+				func() (l27.SystemSshkey, error) { return Level27Client.SystemSshKeysGetSingle(systemID, keyID) },
+				func(ss l27.SystemSshkey) string { return ss.ShsStatus },
+				[]string{"ok", "updating", "deleting", "to_delete"},
+			)
+		})
+	}
+
+	var err error
+	waitIndicator(func() {
+		err = group.Wait()
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return Level27Client.OrganisationUserSshKeysDelete(orgID, userID, keyID)
+}
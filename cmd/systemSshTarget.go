@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/level27/l27-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Resolving how to connect to a system via SSH, shared by commands like lvl system ssh/scp/exec.
+
+// Options passed with addSshFlags.
+var optSshJump string
+var optSshUser string
+var optSshIdentityFile string
+var optSshIpv6 bool
+var optSshInternal bool
+var optSshNetwork string
+
+// SSH key passed with addSshKeyFlag.
+var optSshKey string
+
+// Add the --jump, --user and --identity-file flags to a command that connects to systems via SSH.
+func addSshFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&optSshJump, "jump", "", "System to use as jump host (bastion), overriding ssh_jump and ssh_jump_groups from the config file. Use 'none' to connect directly")
+	flags.StringVar(&optSshUser, "user", "", "User to log in as, overriding ssh_users from the config file (default root)")
+	flags.StringVar(&optSshIdentityFile, "identity-file", "", "Private key file to log in with, passed to ssh -i")
+	flags.BoolVar(&optSshIpv6, "ipv6", false, "Connect to an IPv6 address of the system")
+	flags.BoolVar(&optSshInternal, "internal", false, "Connect to an address on an internal network of the system")
+	flags.StringVar(&optSshNetwork, "network", "", "Connect to an address on the system's network with this name")
+}
+
+// Add the --key flag to a command that adds SSH keys to systems.
+func addSshKeyFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&optSshKey, "key", "", "Name or ID of the SSH key to add to the system, instead of your favorite key")
+}
+
+// Get the SSH key to add to systems: the one passed with --key, or the favorite key.
+func getSshKeyID() (l27.IntID, error) {
+	if optSshKey != "" {
+		return resolveSshKey(viper.GetInt32("org_id"), viper.GetInt32("user_id"), optSshKey)
+	}
+
+	favoriteKeyID := viper.GetInt32("ssh_favoritekey")
+	if favoriteKeyID == 0 {
+		return 0, fmt.Errorf("no favorite SSH key configured. Use 'lvl sshkey favorite' to configure one, or pass --key")
+	}
+
+	return favoriteKeyID, nil
+}
+
+// How to connect to a system via SSH.
+type sshTarget struct {
+	System l27.System
+	// Address to connect to.
+	Host string
+	User string
+	// Jump host to connect through, as user@host. Empty to connect directly.
+	Jump string
+	// Private key file to log in with. Empty to let ssh pick.
+	IdentityFile string
+}
+
+// Get the user@host destination to pass to ssh.
+func (t sshTarget) Destination() string {
+	return fmt.Sprintf("%s@%s", t.User, t.Host)
+}
+
+// Get the user@host destination to use in a remote file for scp, with IPv6 addresses in brackets.
+func (t sshTarget) ScpDestination() string {
+	if strings.Contains(t.Host, ":") {
+		return fmt.Sprintf("%s@[%s]", t.User, t.Host)
+	}
+
+	return t.Destination()
+}
+
+// Get the options to pass to ssh (or scp, ...) before the destination.
+func (t sshTarget) Options() []string {
+	options := []string{}
+	if t.IdentityFile != "" {
+		options = append(options, "-i", t.IdentityFile)
+	}
+
+	if key, value := t.ProxyOption(); key != "" {
+		options = append(options, "-o", fmt.Sprintf("%s=%s", key, value))
+	}
+
+	return options
+}
+
+// Get the ssh option to connect through the jump host, empty if there is none.
+// ProxyJump does not pass -i on to the jump host, so with an identity file we run the jump ourselves with ProxyCommand.
+func (t sshTarget) ProxyOption() (string, string) {
+	if t.Jump == "" {
+		return "", ""
+	}
+
+	if t.IdentityFile == "" {
+		return "ProxyJump", t.Jump
+	}
+
+	return "ProxyCommand", fmt.Sprintf("ssh -i %s -W %%h:%%p %s", shellQuote(t.IdentityFile), t.Jump)
+}
+
+// Quote a value to be passed as a single argument through a POSIX shell.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// Figure out how to connect to a system via SSH, and make sure the SSH key is on it.
+// If a jump host is configured for the system, the key is also added to the jump host and the system's internal address is used.
+// If sshKeyID is 0, no keys are added.
+func prepareSshTarget(systemID l27.IntID, sshKeyID l27.IntID, preferIP bool) (sshTarget, error) {
+	system, err := Level27Client.SystemGetSingle(systemID)
+	if err != nil {
+		return sshTarget{}, err
+	}
+
+	target := sshTarget{System: system, User: getSshUser(system), IdentityFile: optSshIdentityFile}
+
+	// Add the key to the system while we figure out the address.
+	taskSshKey := taskRunVoid(func() error {
+		if sshKeyID == 0 {
+			return nil
+		}
+
+		return waitEnsureSshKey(systemID, sshKeyID)
+	})
+
+	jump, err := resolveSshJump(getSshJumpName(system), sshKeyID)
+	if err == nil && jump != nil && jump.systemID != system.ID {
+		target.Jump = jump.destination
+		target.Host, err = sshSelectAddress(system, preferIP, true)
+	} else if err == nil {
+		target.Host, err = sshSelectAddress(system, preferIP, false)
+	}
+
+	keyErr := <-taskSshKey
+	if err != nil {
+		return sshTarget{}, err
+	}
+
+	if keyErr != nil {
+		return sshTarget{}, keyErr
+	}
+
+	return target, nil
+}
+
+// Get the name of the jump host to use for a system, empty if there is none.
+// In order of priority, this is configured with --jump, per systemgroup with ssh_jump_groups or for all systems with ssh_jump:
+//
+//	ssh_jump: my-bastion
+//	ssh_jump_groups:
+//	  customer-a: customer-a-bastion
+func getSshJumpName(system l27.System) string {
+	if optSshJump != "" {
+		if optSshJump == "none" {
+			return ""
+		}
+
+		return optSshJump
+	}
+
+	// Viper keys are case-insensitive.
+	groups := viper.GetStringMapString("ssh_jump_groups")
+	for _, group := range system.Groups {
+		if jump, ok := groups[strings.ToLower(group.Name)]; ok {
+			return jump
+		}
+	}
+
+	return viper.GetString("ssh_jump")
+}
+
+// Get the user to log in as on a system.
+// This is the user passed with --user, the user configured for the system in the config file, or root:
+//
+//	ssh_users:
+//	  my-app-server: deploy
+func getSshUser(system l27.System) string {
+	if optSshUser != "" {
+		return optSshUser
+	}
+
+	return getConfigSshUser(system)
+}
+
+// Get the user configured for a system in ssh_users, or root.
+func getConfigSshUser(system l27.System) string {
+	// Viper keys are case-insensitive.
+	if user, ok := viper.GetStringMapString("ssh_users")[strings.ToLower(system.Name)]; ok {
+		return user
+	}
+
+	return "root"
+}
+
+// A resolved jump host.
+type sshJumpHost struct {
+	once        sync.Once
+	systemID    l27.IntID
+	destination string
+	err         error
+}
+
+// Jump hosts are resolved once, even if many systems are connected to through them at the same time.
+var sshJumpHosts = map[string]*sshJumpHost{}
+var sshJumpHostsLock sync.Mutex
+
+// Resolve a jump host by system name/ID, and make sure the SSH key is on it.
+// Returns nil if name is empty.
+func resolveSshJump(name string, sshKeyID l27.IntID) (*sshJumpHost, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	sshJumpHostsLock.Lock()
+	jump, ok := sshJumpHosts[name]
+	if !ok {
+		jump = &sshJumpHost{}
+		sshJumpHosts[name] = jump
+	}
+	sshJumpHostsLock.Unlock()
+
+	jump.once.Do(func() {
+		jump.systemID, jump.err = resolveSystem(name)
+		if jump.err != nil {
+			return
+		}
+
+		if sshKeyID != 0 {
+			jump.err = waitEnsureSshKey(jump.systemID, sshKeyID)
+			if jump.err != nil {
+				return
+			}
+		}
+
+		system, err := Level27Client.SystemGetSingle(jump.systemID)
+		if err != nil {
+			jump.err = err
+			return
+		}
+
+		// --user is meant for the target system, the jump host only uses the configured user.
+		host, err := sshResolveSystemHost(system, false)
+		jump.destination, jump.err = fmt.Sprintf("%s@%s", getConfigSshUser(system), host), err
+	})
+
+	if jump.err != nil {
+		return nil, fmt.Errorf("jump host '%s': %s", name, jump.err.Error())
+	}
+
+	return jump, nil
+}
+
+// Get the systems of all jump hosts resolved so far, e.g. to remove an SSH key from them again.
+func resolvedSshJumpSystems() []l27.IntID {
+	sshJumpHostsLock.Lock()
+	defer sshJumpHostsLock.Unlock()
+
+	systems := []l27.IntID{}
+	for _, jump := range sshJumpHosts {
+		if jump.systemID != 0 {
+			systems = append(systems, jump.systemID)
+		}
+	}
+
+	return systems
+}
+
+// An address of a system, on one of its networks.
+type systemAddress struct {
+	Network string `json:"network"`
+	Type    string `json:"type"`
+	Family  string `json:"family"`
+	Address string `json:"address"`
+}
+
+// Get all addresses of a system, public networks first.
+func getSystemAddresses(system l27.System) []systemAddress {
+	networks := append([]l27.SystemNetwork{}, system.Networks...)
+	sort.SliceStable(networks, func(i, j int) bool {
+		return networks[i].NetPublic && !networks[j].NetPublic
+	})
+
+	addresses := []systemAddress{}
+	for _, network := range networks {
+		netType := systemNetworkType(network)
+		for _, ip := range network.Ips {
+			for _, address := range []systemAddress{
+				{Family: "IPv4", Address: ip.PublicIpv4},
+				{Family: "IPv4", Address: ip.Ipv4},
+				{Family: "IPv6", Address: ip.PublicIpv6},
+				{Family: "IPv6", Address: ip.Ipv6},
+			} {
+				if address.Address != "" {
+					address.Network = network.Name
+					address.Type = netType
+					addresses = append(addresses, address)
+				}
+			}
+		}
+	}
+
+	return addresses
+}
+
+// Pick the address to connect to a system on, taking --ipv6, --internal and --network into account.
+// If jumped is set, the system is connected to through a jump host, so an internal address is preferred.
+func sshSelectAddress(system l27.System, preferIP bool, jumped bool) (string, error) {
+	if !optSshIpv6 && !optSshInternal && optSshNetwork == "" {
+		if jumped {
+			return sshResolveInternalHost(system)
+		}
+
+		return sshResolveSystemHost(system, preferIP)
+	}
+
+	family := "IPv4"
+	if optSshIpv6 {
+		family = "IPv6"
+	}
+
+	for _, address := range getSystemAddresses(system) {
+		if address.Family != family {
+			continue
+		}
+
+		if optSshNetwork != "" && !strings.EqualFold(address.Network, optSshNetwork) {
+			continue
+		}
+
+		if optSshNetwork == "" && (optSshInternal || jumped) && address.Type != "internal" {
+			continue
+		}
+
+		return address.Address, nil
+	}
+
+	where := "on any network"
+	if optSshNetwork != "" {
+		where = fmt.Sprintf("on network '%s'", optSshNetwork)
+	} else if optSshInternal || jumped {
+		where = "on an internal network"
+	}
+
+	return "", fmt.Errorf("system '%s' has no %s address %s. Use 'lvl system address %s' to see its addresses", system.Name, family, where, system.Name)
+}
+
+// Get the address of a system on its internal network, to connect to it through a jump host.
+// Falls back to other addresses if the system has no internal network.
+func sshResolveInternalHost(system l27.System) (string, error) {
+	for _, network := range system.Networks {
+		if !network.NetInternal {
+			continue
+		}
+
+		for _, ip := range network.Ips {
+			if ip.Ipv4 != "" {
+				return ip.Ipv4, nil
+			}
+		}
+	}
+
+	return sshResolveSystemHost(system, true)
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	// SYSTEM TUNNEL
+	systemCmd.AddCommand(systemTunnelCmd)
+	flags := systemTunnelCmd.Flags()
+	flags.IntVar(&systemTunnelSocks, "socks", 0, "Local port to run a SOCKS proxy on, to connect through the system")
+	flags.BoolVar(&systemTunnelReconnect, "reconnect", true, "Reconnect when the tunnel drops")
+	addSshFlags(systemTunnelCmd)
+	addSshKeyFlag(systemTunnelCmd)
+}
+
+// Forward spec as taken by ssh -L: [bind_address:]port:host:hostport
+var systemTunnelForwardRegex = regexp.MustCompile(`^(?:([^:\[\]]+|\[[^\]]+\]):)?(\d+):([^:\[\]]+|\[[^\]]+\]):(\d+)$`)
+
+// Longest time to wait between reconnect attempts.
+const systemTunnelMaxBackoff = 30 * time.Second
+
+// If the first connection exits within this time, the tunnel is considered broken and not reconnected.
+const systemTunnelStartupTime = 5 * time.Second
+
+// SYSTEM TUNNEL
+var systemTunnelSocks int
+var systemTunnelReconnect bool
+var systemTunnelCmd = &cobra.Command{
+	Use:   "tunnel <system> [[bind_address:]localport:remotehost:remoteport...] [--socks port]",
+	Short: "Forward local ports to a system via SSH",
+	Long: `Forward local ports to a system via SSH.
+Every forward makes localport on your machine connect to remotehost:remoteport as seen from the system, like 'ssh -L'.
+With --socks, a SOCKS proxy is started on the given local port instead, like 'ssh -D'.
+Your favorite SSH key (or the one passed with --key) is added to the system if necessary. The tunnel keeps running until interrupted and reconnects when it drops.
+
+If no forwards are given, the forwards configured for the system in the config file are used:
+  ssh_tunnels:
+    my-database-server:
+      - 5432:localhost:5432
+      - 6379:10.0.0.5:6379`,
+	Example: `lvl system tunnel my-database-server 5432:localhost:5432
+lvl system tunnel my-database-server 13306:localhost:3306 16379:localhost:6379
+lvl system tunnel my-server --socks 1080`,
+
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sshKeyID, err := getSshKeyID()
+		if err != nil {
+			return err
+		}
+
+		systemID, err := resolveSystem(args[0])
+		if err != nil {
+			return err
+		}
+
+		system, err := Level27Client.SystemGetSingle(systemID)
+		if err != nil {
+			return err
+		}
+
+		forwards := args[1:]
+		if len(forwards) == 0 && systemTunnelSocks == 0 {
+			forwards = getConfigTunnels(args[0], system.Name)
+			if len(forwards) == 0 {
+				return fmt.Errorf("no forwards given and none configured for system '%s' in ssh_tunnels", system.Name)
+			}
+		}
+
+		for _, forward := range forwards {
+			if err := validateTunnelForward(forward); err != nil {
+				return err
+			}
+		}
+
+		if systemTunnelSocks < 0 || systemTunnelSocks > 65535 {
+			return fmt.Errorf("invalid --socks port: %d", systemTunnelSocks)
+		}
+
+		target, err := prepareSshTarget(systemID, sshKeyID, false)
+		if err != nil {
+			return err
+		}
+
+		sshArgs := []string{
+			"-N",
+			"-o", "ExitOnForwardFailure=yes",
+			"-o", "ServerAliveInterval=15",
+			"-o", "ServerAliveCountMax=3",
+		}
+
+		for _, forward := range forwards {
+			sshArgs = append(sshArgs, "-L", forward)
+			fmt.Fprintf(os.Stderr, "Forwarding %s via %s\n", forward, system.Name)
+		}
+
+		if systemTunnelSocks != 0 {
+			sshArgs = append(sshArgs, "-D", strconv.Itoa(systemTunnelSocks))
+			fmt.Fprintf(os.Stderr, "SOCKS proxy on port %d via %s\n", systemTunnelSocks, system.Name)
+		}
+
+		sshArgs = append(sshArgs, target.Options()...)
+		sshArgs = append(sshArgs, target.Destination())
+
+		return runTunnel(sshArgs, systemTunnelReconnect)
+	},
+}
+
+// Get the forwards configured for a system in the config file.
+// Systems can be configured by the name they are passed as, or by their actual name.
+func getConfigTunnels(arg string, systemName string) []string {
+	// Viper keys are case-insensitive.
+	tunnels := viper.GetStringMapStringSlice("ssh_tunnels")
+	if forwards, ok := tunnels[strings.ToLower(arg)]; ok {
+		return forwards
+	}
+
+	return tunnels[strings.ToLower(systemName)]
+}
+
+// Check that a forward is in the form ssh -L takes.
+func validateTunnelForward(forward string) error {
+	match := systemTunnelForwardRegex.FindStringSubmatch(forward)
+	if match == nil {
+		return fmt.Errorf("invalid forward: '%s'. Expected [bind_address:]localport:remotehost:remoteport", forward)
+	}
+
+	for _, port := range []string{match[2], match[4]} {
+		if value, err := strconv.Atoi(port); err != nil || value < 1 || value > 65535 {
+			return fmt.Errorf("invalid port in forward '%s': %s", forward, port)
+		}
+	}
+
+	return nil
+}
+
+// Run ssh until interrupted, restarting it when it exits if reconnect is set.
+func runTunnel(sshArgs []string, reconnect bool) error {
+	// The interrupt also reaches ssh itself, so we only have to remember to not reconnect.
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		started := time.Now()
+
+		sshCmd := exec.Command("ssh", sshArgs...)
+		sshCmd.Stdin = os.Stdin
+		sshCmd.Stdout = os.Stdout
+		sshCmd.Stderr = os.Stderr
+		err := sshCmd.Run()
+
+		select {
+		case <-interrupted:
+			return nil
+		default:
+		}
+
+		if !reconnect {
+			return err
+		}
+
+		// If the very first attempt fails right away, something like a local port that is already in use is wrong.
+		// Reconnecting won't fix that.
+		if attempt == 0 && time.Since(started) < systemTunnelStartupTime {
+			if err == nil {
+				err = fmt.Errorf("connection closed")
+			}
+
+			return fmt.Errorf("tunnel failed to start: %s", err.Error())
+		}
+
+		// A tunnel that was up for a while dropped, try again quickly.
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+
+		reason := "connection closed"
+		if err != nil {
+			reason = err.Error()
+		}
+
+		fmt.Fprintf(os.Stderr, "Tunnel dropped (%s), reconnecting in %s\n", reason, backoff)
+
+		select {
+		case <-interrupted:
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > systemTunnelMaxBackoff {
+			backoff = systemTunnelMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/level27/l27-go"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	// SYSTEMGROUP ACTIONS
+	systemgroupCmd.AddCommand(systemgroupActionsCmd)
+
+	systemgroupActionsCmd.AddCommand(systemgroupActionsRebootCmd)
+	addSystemgroupActionFlags(systemgroupActionsRebootCmd)
+
+	systemgroupActionsCmd.AddCommand(systemgroupActionsShutdownCmd)
+	addSystemgroupActionFlags(systemgroupActionsShutdownCmd)
+
+	systemgroupActionsCmd.AddCommand(systemgroupActionsStartCmd)
+	addSystemgroupActionFlags(systemgroupActionsStartCmd)
+}
+
+func addSystemgroupActionFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.BoolVar(&systemgroupActionRolling, "rolling", false, "Run the action on a few systems at a time, waiting for them to be healthy before continuing")
+	flags.IntVar(&systemgroupActionBatch, "batch", 1, "With --rolling, the amount of systems to run the action on at the same time")
+	flags.DurationVar(&systemgroupActionPause, "pause", 0, "With --rolling, how long to wait between batches (e.g. 60s)")
+	flags.BoolVar(&systemgroupActionMaintenance, "maintenance", false, "Put every system in maintenance while the action runs, silencing alerts")
+	flags.Int32Var(&systemgroupActionMaintenanceDuration, "maintenance-duration", 60, "With --maintenance, how long maintenance lasts at most, in minutes")
+	flags.DurationVar(&systemgroupActionTimeout, "timeout", 15*time.Minute, "How long to wait for a system to be healthy after the action")
+	flags.BoolVar(&systemgroupActionSkipChecks, "skip-checks", false, "Don't wait for the checks of a system to pass after the action")
+	addDeleteConfirmFlag(cmd)
+}
+
+// Interval to poll systems and checks at while waiting for them.
+const systemgroupActionPollInterval = 5 * time.Second
+
+// Actions are queued, so a system can still look healthy right after a reboot was requested.
+// We wait this long for the reboot to show up in the system status, before considering the reboot failed.
+const systemgroupActionStartGrace = 2 * time.Minute
+
+// Result of an action on a single system of a systemgroup.
+type systemgroupActionResult struct {
+	System   string `json:"system"`
+	Result   string `json:"result"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SYSTEMGROUP ACTIONS
+var systemgroupActionsCmd = &cobra.Command{
+	Use:   "actions",
+	Short: "Run actions such as rebooting on all systems in a systemgroup",
+	Long: `Run actions such as rebooting on all systems in a systemgroup.
+After the action, every system is waited on until it is healthy: for reboot and start this means running with an OK status and all checks passing, for shutdown it means stopped.
+With --rolling, the action runs on --batch systems at a time, and the next batch only starts once the previous one is healthy.
+The roll-out is aborted on the first system that fails, leaving the remaining systems untouched.
+You are asked for confirmation before anything runs, pass --yes to skip this.`,
+}
+
+var systemgroupActionRolling bool
+var systemgroupActionBatch int
+var systemgroupActionPause time.Duration
+var systemgroupActionMaintenance bool
+var systemgroupActionMaintenanceDuration int32
+var systemgroupActionTimeout time.Duration
+var systemgroupActionSkipChecks bool
+
+var systemgroupActionsRebootCmd = &cobra.Command{
+	Use:   "reboot <systemgroup>",
+	Short: "Reboot all systems in a systemgroup",
+	Example: `lvl systemgroup actions reboot webservers --rolling
+lvl systemgroup actions reboot webservers --rolling --batch 2 --pause 60s --maintenance`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error { return runSystemgroupAction("reboot", args[0]) },
+}
+
+var systemgroupActionsShutdownCmd = &cobra.Command{
+	Use:   "shutdown <systemgroup>",
+	Short: "Shut down all systems in a systemgroup",
+	Args:  cobra.ExactArgs(1),
+	RunE:  func(cmd *cobra.Command, args []string) error { return runSystemgroupAction("shutdown", args[0]) },
+}
+
+var systemgroupActionsStartCmd = &cobra.Command{
+	Use:   "start <systemgroup>",
+	Short: "Start all systems in a systemgroup",
+	Args:  cobra.ExactArgs(1),
+	RunE:  func(cmd *cobra.Command, args []string) error { return runSystemgroupAction("start", args[0]) },
+}
+
+func runSystemgroupAction(action string, group string) error {
+	if systemgroupActionBatch < 1 {
+		return fmt.Errorf("--batch must be at least 1")
+	}
+
+	if !systemgroupActionRolling && (systemgroupActionBatch != 1 || systemgroupActionPause != 0) {
+		return fmt.Errorf("--batch and --pause can only be used with --rolling")
+	}
+
+	systems, err := resolveSystemSelection(group, nil)
+	if err != nil {
+		return err
+	}
+
+	if len(systems) == 0 {
+		return fmt.Errorf("systemgroup '%s' has no systems", group)
+	}
+
+	batchSize := len(systems)
+	if systemgroupActionRolling {
+		batchSize = systemgroupActionBatch
+	}
+
+	if !optDeleteConfirmed {
+		how := "all at once"
+		if systemgroupActionRolling {
+			how = fmt.Sprintf("%d at a time", batchSize)
+		}
+
+		if !confirmPrompt(fmt.Sprintf("Run %s on %d systems in systemgroup '%s', %s?", action, len(systems), group, how)) {
+			return nil
+		}
+	}
+
+	results := make([]systemgroupActionResult, len(systems))
+	for i, system := range systems {
+		results[i] = systemgroupActionResult{System: system.Name, Result: "skipped"}
+	}
+
+	var failed *systemgroupActionResult
+	for start := 0; start < len(systems) && failed == nil; start += batchSize {
+		if start != 0 && systemgroupActionPause != 0 {
+			fmt.Fprintf(os.Stderr, "Pausing for %s\n", systemgroupActionPause)
+			time.Sleep(systemgroupActionPause)
+		}
+
+		end := start + batchSize
+		if end > len(systems) {
+			end = len(systems)
+		}
+
+		batch := systems[start:end]
+		names := []string{}
+		for _, system := range batch {
+			names = append(names, system.Name)
+		}
+
+		fmt.Fprintf(os.Stderr, "Running %s on %s\n", action, strings.Join(names, ", "))
+
+		tasks := []<-chan resultPair[time.Duration]{}
+		for _, system := range batch {
+			system := system
+			tasks = append(tasks, taskRun(func() (time.Duration, error) {
+				began := time.Now()
+				err := runSystemAndWait(system, action)
+				return time.Since(began), err
+			}))
+		}
+
+		for i, task := range tasks {
+			taskResult := <-task
+			result := &results[start+i]
+			result.Result = "done"
+			result.Duration = taskResult.Result.Round(time.Second).String()
+			if taskResult.Error != nil {
+				result.Result = "failed"
+				result.Error = taskResult.Error.Error()
+				if failed == nil {
+					failed = result
+				}
+			}
+		}
+	}
+
+	outputFormatTable(
+		results,
+		[]string{"SYSTEM", "RESULT", "DURATION", "ERROR"},
+		[]string{"System", "Result", "Duration", "Error"})
+
+	if failed != nil {
+		return fmt.Errorf("%s aborted, failed on %s: %s", action, failed.System, failed.Error)
+	}
+
+	return nil
+}
+
+// Run an action on a system, and wait for it to be healthy afterwards.
+func runSystemAndWait(system l27.System, action string) error {
+	deadline := time.Now().Add(systemgroupActionTimeout)
+
+	if systemgroupActionMaintenance {
+		_, err := Level27Client.SystemActionStartMaintenance(system.ID, systemgroupActionMaintenanceDuration)
+		if err != nil {
+			return fmt.Errorf("failed to start maintenance: %s", err.Error())
+		}
+	}
+
+	_, err := Level27Client.SystemAction(system.ID, action)
+	if err != nil {
+		return err
+	}
+
+	err = waitSystemRunningStatus(system.ID, action, deadline)
+	if err != nil {
+		return err
+	}
+
+	if action != "shutdown" && !systemgroupActionSkipChecks {
+		err = waitSystemChecksPassing(system.ID, deadline)
+		if err != nil {
+			return err
+		}
+	}
+
+	// On failure the system stays in maintenance, so it can be looked at without alerts going off.
+	if systemgroupActionMaintenance {
+		_, err := Level27Client.SystemAction(system.ID, "stopMaintenance")
+		if err != nil {
+			return fmt.Errorf("failed to stop maintenance: %s", err.Error())
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: %s done\n", system.Name, action)
+	return nil
+}
+
+// Wait for a system to reach the running status an action leads to, with a healthy status.
+func waitSystemRunningStatus(systemID l27.IntID, action string, deadline time.Time) error {
+	want := "running"
+	if action == "shutdown" {
+		want = "stopped"
+	}
+
+	// A rebooted system ends up in the same state it started in, so we have to see it change first.
+	changed := action != "reboot"
+	graceDeadline := time.Now().Add(systemgroupActionStartGrace)
+	for {
+		system, err := Level27Client.SystemGetSingle(systemID)
+		if err != nil {
+			return err
+		}
+
+		if system.StatusCategory == "red" {
+			return fmt.Errorf("system has status %s", system.Status)
+		}
+
+		healthy := system.StatusCategory == "green" && system.RunningStatus == want
+		if !healthy {
+			changed = true
+		}
+
+		if healthy && changed {
+			return nil
+		}
+
+		// Checks passing now would be results from before the reboot, so this can't count as healthy.
+		if !changed && time.Now().After(graceDeadline) {
+			return fmt.Errorf("system did not start rebooting within %s", systemgroupActionStartGrace)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for system to be %s, status is %s (%s)", want, system.Status, system.RunningStatus)
+		}
+
+		time.Sleep(systemgroupActionPollInterval)
+	}
+}
+
+// Wait for all checks on a system to have an OK status.
+func waitSystemChecksPassing(systemID l27.IntID, deadline time.Time) error {
+	for {
+		checks, err := getAllPages("", func(params l27.CommonGetParams) ([]l27.SystemCheckGet, error) {
+			return Level27Client.SystemCheckGetList(systemID, params)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		failing := []string{}
+		for _, check := range checks {
+			if !strings.EqualFold(check.Status, "ok") {
+				failing = append(failing, fmt.Sprintf("%s (%s)", check.CheckType, check.Status))
+			}
+		}
+
+		if len(failing) == 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for checks to pass: %s", strings.Join(failing, ", "))
+		}
+
+		time.Sleep(systemgroupActionPollInterval)
+	}
+}
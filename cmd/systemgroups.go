@@ -89,6 +89,80 @@ func resolveSystemgroup(arg string) (l27.IntID, error) {
 	return res.ID, err
 }
 
+// Get all systems that are a member of a systemgroup.
+func getSystemgroupSystems(systemgroupID l27.IntID) ([]l27.System, error) {
+	systems, err := getAllPages("", Level27Client.SystemGetList)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterSystemgroupSystems(systems, systemgroupID), nil
+}
+
+// Get the systems that are in a systemgroup from a list of systems.
+func filterSystemgroupSystems(systems []l27.System, systemgroupID l27.IntID) []l27.System {
+	members := []l27.System{}
+	for _, system := range systems {
+		for _, group := range system.Groups {
+			if group.ID == systemgroupID {
+				members = append(members, system)
+				break
+			}
+		}
+	}
+
+	return members
+}
+
+// Resolve the systems selected with a --group or --systems flag, for commands that act on multiple systems.
+// Exactly one of group and systems must be given.
+func resolveSystemSelection(group string, systems []string) ([]l27.System, error) {
+	if (group == "") == (len(systems) == 0) {
+		return nil, errors.New("specify either --group or --systems")
+	}
+
+	if group != "" {
+		systemgroupID, err := resolveSystemgroup(group)
+		if err != nil {
+			return nil, err
+		}
+
+		members, err := getSystemgroupSystems(systemgroupID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(members) == 0 {
+			return nil, fmt.Errorf("systemgroup '%s' has no systems", group)
+		}
+
+		return members, nil
+	}
+
+	result := []l27.System{}
+	seen := map[l27.IntID]bool{}
+	for _, arg := range systems {
+		systemID, err := resolveSystem(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[systemID] {
+			continue
+		}
+
+		seen[systemID] = true
+		system, err := Level27Client.SystemGetSingle(systemID)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, system)
+	}
+
+	return result, nil
+}
+
 // ------------------------------------------------- SYSTEMSGROUPS (GET / CREATE  / UPDATE / DELETE)-------------------------------------------------
 // ---------------- DESCRIBE
 var systemgroupDescribeCmd = &cobra.Command{
@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Actions to take on a check to bring a system in line with a check profile, see PlanCheckProfile.
+const (
+	// The check matches the profile.
+	CheckProfileUnchanged = "unchanged"
+	// The check is missing and has to be added.
+	CheckProfileAdd = "add"
+	// The check exists but some of its parameters differ from the profile.
+	CheckProfileUpdate = "update"
+	// The check is not in the profile and has to be removed.
+	CheckProfileRemove = "remove"
+	// The check is not in the profile, but is left alone.
+	CheckProfileExtra = "extra"
+)
+
+// A set of monitoring checks that systems should have, loaded from YAML:
+//
+//	checks:
+//	  - type: http
+//	    parameters:
+//	      url: https://example.com/health
+//	  - type: disk
+type CheckProfile struct {
+	Checks []CheckProfileCheck `yaml:"checks"`
+}
+
+// A check in a check profile. Parameters that aren't given are left alone on existing checks.
+type CheckProfileCheck struct {
+	Type       string                 `yaml:"type"`
+	Parameters map[string]interface{} `yaml:"parameters"`
+}
+
+// A check that exists on a system, to compare against a check profile.
+type CheckProfileExisting struct {
+	ID   int
+	Type string
+	// Current parameter values, as formatted by CheckParameterString.
+	Parameters map[string]string
+}
+
+// A single action to take to bring a system in line with a check profile.
+type CheckProfileChange struct {
+	Action string
+	Type   string
+	// ID of the existing check, 0 when adding.
+	CheckID int
+	// Parameters from the profile, for adding and updating.
+	Parameters map[string]interface{}
+	// Names of the parameters that differ from the profile, when updating.
+	Drifted []string
+}
+
+// Load a check profile from a YAML file.
+func LoadCheckProfile(path string) (CheckProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CheckProfile{}, err
+	}
+
+	profile := CheckProfile{}
+	if err := yaml.UnmarshalStrict(data, &profile); err != nil {
+		return CheckProfile{}, fmt.Errorf("failed to parse %s: %s", path, err.Error())
+	}
+
+	if len(profile.Checks) == 0 {
+		return CheckProfile{}, fmt.Errorf("%s does not declare any checks", path)
+	}
+
+	for i, check := range profile.Checks {
+		if check.Type == "" {
+			return CheckProfile{}, fmt.Errorf("check %d in %s has no type", i+1, path)
+		}
+
+		for name, value := range check.Parameters {
+			check.Parameters[name] = convertYamlValue(value)
+		}
+	}
+
+	return profile, nil
+}
+
+// Convert the maps yaml.v2 decodes nested objects into (map[interface{}]interface{}),
+// which can't be marshalled to JSON for the API, to map[string]interface{}.
+func convertYamlValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		converted := map[string]interface{}{}
+		for key, item := range value {
+			converted[fmt.Sprint(key)] = convertYamlValue(item)
+		}
+
+		return converted
+	case []interface{}:
+		converted := make([]interface{}, len(value))
+		for i, item := range value {
+			converted[i] = convertYamlValue(item)
+		}
+
+		return converted
+	default:
+		return value
+	}
+}
+
+// Format a check parameter value for comparison.
+// Values from a profile and from the API have different types (e.g. int vs float64), but format the same.
+func CheckParameterString(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		items := []string{}
+		for _, item := range value {
+			items = append(items, CheckParameterString(item))
+		}
+
+		return strings.Join(items, ",")
+	case []string:
+		return strings.Join(value, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Get the names of the parameters of an existing check that differ from a check in a profile.
+func checkProfileDrift(check CheckProfileCheck, existing CheckProfileExisting) []string {
+	drifted := []string{}
+	for name, value := range check.Parameters {
+		current, ok := existing.Parameters[name]
+		if !ok || current != CheckParameterString(value) {
+			drifted = append(drifted, name)
+		}
+	}
+
+	sort.Strings(drifted)
+	return drifted
+}
+
+// Work out the changes needed to bring the existing checks of a system in line with a profile.
+//
+// A profile can have several checks of the same type (e.g. multiple http checks).
+// Existing checks are matched to the profile by type, preferring checks that already have the right parameters.
+// Checks that are not in the profile are removed when removeExtra is set, or reported as extra otherwise.
+func PlanCheckProfile(profile CheckProfile, existing []CheckProfileExisting, removeExtra bool) []CheckProfileChange {
+	matched := make([]bool, len(existing))
+	changes := make([]*CheckProfileChange, len(profile.Checks))
+
+	// First match checks that don't need any changes.
+	for i, check := range profile.Checks {
+		for j, current := range existing {
+			if matched[j] || current.Type != check.Type || len(checkProfileDrift(check, current)) != 0 {
+				continue
+			}
+
+			matched[j] = true
+			changes[i] = &CheckProfileChange{Action: CheckProfileUnchanged, Type: check.Type, CheckID: current.ID}
+			break
+		}
+	}
+
+	// Then update the remaining checks of the same type, or add them if there are none left.
+	for i, check := range profile.Checks {
+		if changes[i] != nil {
+			continue
+		}
+
+		changes[i] = &CheckProfileChange{Action: CheckProfileAdd, Type: check.Type, Parameters: check.Parameters}
+		for j, current := range existing {
+			if matched[j] || current.Type != check.Type {
+				continue
+			}
+
+			matched[j] = true
+			changes[i].Action = CheckProfileUpdate
+			changes[i].CheckID = current.ID
+			changes[i].Drifted = checkProfileDrift(check, current)
+			break
+		}
+	}
+
+	result := []CheckProfileChange{}
+	for _, change := range changes {
+		result = append(result, *change)
+	}
+
+	extraAction := CheckProfileExtra
+	if removeExtra {
+		extraAction = CheckProfileRemove
+	}
+
+	for j, current := range existing {
+		if !matched[j] {
+			result = append(result, CheckProfileChange{Action: extraAction, Type: current.Type, CheckID: current.ID})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,120 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/level27/lvl/utils"
+)
+
+func TestCheckParameterString(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{"https://example.com", "https://example.com"},
+		{10, "10"},
+		{float64(10), "10"},
+		{0.5, "0.5"},
+		{true, "true"},
+		{[]interface{}{"a", 1}, "a,1"},
+		{[]string{"a", "b"}, "a,b"},
+	}
+
+	for _, test := range tests {
+		if got := utils.CheckParameterString(test.value); got != test.want {
+			t.Errorf("CheckParameterString(%#v): got '%s', want '%s'", test.value, got, test.want)
+		}
+	}
+}
+
+func TestPlanCheckProfile(t *testing.T) {
+	profile := utils.CheckProfile{Checks: []utils.CheckProfileCheck{
+		{Type: "http", Parameters: map[string]interface{}{"url": "https://b.example.com", "timeout": 10}},
+		{Type: "http", Parameters: map[string]interface{}{"url": "https://a.example.com", "timeout": 10}},
+		{Type: "disk", Parameters: map[string]interface{}{"warning": 80}},
+		{Type: "load"},
+	}}
+
+	existing := []utils.CheckProfileExisting{
+		{ID: 1, Type: "http", Parameters: map[string]string{"url": "https://a.example.com", "timeout": "10"}},
+		{ID: 2, Type: "http", Parameters: map[string]string{"url": "https://old.example.com", "timeout": "10"}},
+		{ID: 3, Type: "disk", Parameters: map[string]string{"warning": "90"}},
+		{ID: 4, Type: "ping", Parameters: map[string]string{}},
+	}
+
+	want := []utils.CheckProfileChange{
+		{Action: utils.CheckProfileUpdate, Type: "http", CheckID: 2, Parameters: profile.Checks[0].Parameters, Drifted: []string{"url"}},
+		{Action: utils.CheckProfileUnchanged, Type: "http", CheckID: 1},
+		{Action: utils.CheckProfileUpdate, Type: "disk", CheckID: 3, Parameters: profile.Checks[2].Parameters, Drifted: []string{"warning"}},
+		{Action: utils.CheckProfileAdd, Type: "load"},
+		{Action: utils.CheckProfileExtra, Type: "ping", CheckID: 4},
+	}
+
+	got := utils.PlanCheckProfile(profile, existing, false)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PlanCheckProfile():\ngot  %+v\nwant %+v", got, want)
+	}
+
+	got = utils.PlanCheckProfile(profile, existing, true)
+	if last := got[len(got)-1]; last.Action != utils.CheckProfileRemove || last.CheckID != 4 {
+		t.Errorf("PlanCheckProfile() with removeExtra: got %+v for extra check, want remove", last)
+	}
+}
+
+func TestLoadCheckProfile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "profile.yaml")
+	data := "checks:\n  - type: http\n    parameters:\n      url: https://example.com\n      timeout: 10\n  - type: disk\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err := utils.LoadCheckProfile(path)
+	if err != nil {
+		t.Fatalf("LoadCheckProfile(): %s", err.Error())
+	}
+
+	if len(profile.Checks) != 2 || profile.Checks[0].Type != "http" || profile.Checks[0].Parameters["timeout"] != 10 || profile.Checks[1].Type != "disk" {
+		t.Errorf("LoadCheckProfile(): got %+v", profile)
+	}
+
+	// Nested values have to be sent to the API as JSON.
+	path = filepath.Join(dir, "nested.yaml")
+	data = "checks:\n  - type: http\n    parameters:\n      headers:\n        Host: example.com\n      hosts:\n        - {name: web1, port: 80}\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	profile, err = utils.LoadCheckProfile(path)
+	if err != nil {
+		t.Fatalf("LoadCheckProfile(nested.yaml): %s", err.Error())
+	}
+
+	encoded, err := json.Marshal(profile.Checks[0].Parameters)
+	if want := `{"headers":{"Host":"example.com"},"hosts":[{"name":"web1","port":80}]}`; err != nil || string(encoded) != want {
+		t.Errorf("LoadCheckProfile(nested.yaml): got %s, %v, want %s", encoded, err, want)
+	}
+
+	invalid := map[string]string{
+		"empty.yaml":   "checks: []\n",
+		"notype.yaml":  "checks:\n  - parameters:\n      url: https://example.com\n",
+		"unknown.yaml": "checks:\n  - type: http\n    params:\n      url: https://example.com\n",
+	}
+
+	for name, data := range invalid {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := utils.LoadCheckProfile(path); err == nil {
+			t.Errorf("LoadCheckProfile(%s): expected error", name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Shown in a cookbook diff for a cookbook that a side doesn't have.
+const CookbookMissing = "(missing)"
+
+// Shown in a cookbook diff for a parameter that a side leaves at its default value.
+const CookbookDefault = "(default)"
+
+// The cookbooks of a system and their non-default parameters, by cookbook type.
+type CookbookSet map[string]map[string]interface{}
+
+// A difference between two sets of cookbooks.
+type CookbookDifference struct {
+	CookbookType string `json:"cookbookType" yaml:"cookbookType"`
+	// Empty when one side doesn't have the cookbook at all.
+	Parameter string `json:"parameter,omitempty" yaml:"parameter,omitempty"`
+	A         string `json:"a" yaml:"a"`
+	B         string `json:"b" yaml:"b"`
+}
+
+// Split cookbook parameters into those that can be copied to another system, and the names of those that can't.
+// A parameter can't be copied when its value refers to the system it was set on,
+// i.e. it contains one of the identifiers of that system, such as its hostname or IP addresses.
+func SplitSystemSpecificParameters(parameters map[string]interface{}, identifiers []string) (map[string]interface{}, []string) {
+	portable := map[string]interface{}{}
+	specific := []string{}
+
+	for name, value := range parameters {
+		if containsIdentifier(strings.ToLower(CheckParameterString(value)), identifiers) {
+			specific = append(specific, name)
+		} else {
+			portable[name] = value
+		}
+	}
+
+	sort.Strings(specific)
+	return portable, specific
+}
+
+// Check whether value mentions one of the identifiers as a whole,
+// so "db" is not found in "mysqldb_pool" and "10.0.0.1" not in "10.0.0.10".
+func containsIdentifier(value string, identifiers []string) bool {
+	for _, identifier := range identifiers {
+		identifier = strings.ToLower(identifier)
+		if identifier == "" {
+			continue
+		}
+
+		for offset := 0; ; {
+			index := strings.Index(value[offset:], identifier)
+			if index == -1 {
+				break
+			}
+
+			start := offset + index
+			end := start + len(identifier)
+			if (start == 0 || !isIdentifierChar(value[start-1])) && (end == len(value) || !isIdentifierChar(value[end])) {
+				return true
+			}
+
+			offset = start + 1
+		}
+	}
+
+	return false
+}
+
+// Characters that can be part of a hostname label or IP address, besides the separators between them.
+func isIdentifierChar(chr byte) bool {
+	return chr >= 'a' && chr <= 'z' || chr >= 'A' && chr <= 'Z' || chr >= '0' && chr <= '9' || chr == '-' || chr == '_'
+}
+
+// Load a set of cookbooks from a YAML file:
+//
+//	cookbooks:
+//	  php:
+//	    versions: ["8.1", "8.2"]
+//	  nginx: {}
+func LoadCookbookSet(path string) (CookbookSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := struct {
+		Cookbooks CookbookSet `yaml:"cookbooks"`
+	}{}
+
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", path, err.Error())
+	}
+
+	set := CookbookSet{}
+	for cookbookType, parameters := range file.Cookbooks {
+		if parameters == nil {
+			parameters = map[string]interface{}{}
+		}
+
+		for name, value := range parameters {
+			parameters[name] = convertYamlValue(value)
+		}
+
+		set[cookbookType] = parameters
+	}
+
+	return set, nil
+}
+
+// Compare two sets of cookbooks.
+// Parameters that are set to the default value of the cookbook type (from defaults, by cookbook type and parameter)
+// are the same as parameters that are not set at all.
+func DiffCookbooks(a CookbookSet, b CookbookSet, defaults map[string]map[string]string) []CookbookDifference {
+	types := map[string]bool{}
+	for cookbookType := range a {
+		types[cookbookType] = true
+	}
+
+	for cookbookType := range b {
+		types[cookbookType] = true
+	}
+
+	diffs := []CookbookDifference{}
+	for cookbookType := range types {
+		parametersA, inA := a[cookbookType]
+		parametersB, inB := b[cookbookType]
+
+		if !inA || !inB {
+			diff := CookbookDifference{CookbookType: cookbookType, A: "present", B: "present"}
+			if !inA {
+				diff.A = CookbookMissing
+			} else {
+				diff.B = CookbookMissing
+			}
+
+			diffs = append(diffs, diff)
+			continue
+		}
+
+		names := map[string]bool{}
+		for name := range parametersA {
+			names[name] = true
+		}
+
+		for name := range parametersB {
+			names[name] = true
+		}
+
+		for name := range names {
+			valueA, shownA := cookbookParameterValue(parametersA, name, defaults[cookbookType])
+			valueB, shownB := cookbookParameterValue(parametersB, name, defaults[cookbookType])
+			if valueA != valueB {
+				diffs = append(diffs, CookbookDifference{CookbookType: cookbookType, Parameter: name, A: shownA, B: shownB})
+			}
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].CookbookType != diffs[j].CookbookType {
+			return diffs[i].CookbookType < diffs[j].CookbookType
+		}
+
+		return diffs[i].Parameter < diffs[j].Parameter
+	})
+
+	return diffs
+}
+
+// Get the value of a cookbook parameter to compare, and to show.
+// Parameters that are not set compare as their default value.
+func cookbookParameterValue(parameters map[string]interface{}, name string, defaults map[string]string) (string, string) {
+	value, ok := parameters[name]
+	if !ok {
+		return defaults[name], CookbookDefault
+	}
+
+	shown := CheckParameterString(value)
+	return shown, shown
+}
@@ -0,0 +1,133 @@
+package utils_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/level27/lvl/utils"
+)
+
+func TestSplitSystemSpecificParameters(t *testing.T) {
+	parameters := map[string]interface{}{
+		"versions":   []interface{}{"8.1", "8.2"},
+		"servername": "Web1.example.com",
+		"listen":     []interface{}{"127.0.0.1", "203.0.113.10"},
+		"timeout":    200,
+	}
+
+	identifiers := []string{"web1.example.com", "", "203.0.113.10"}
+
+	portable, specific := utils.SplitSystemSpecificParameters(parameters, identifiers)
+
+	wantPortable := map[string]interface{}{
+		"versions": []interface{}{"8.1", "8.2"},
+		"timeout":  200,
+	}
+
+	if !reflect.DeepEqual(portable, wantPortable) {
+		t.Errorf("SplitSystemSpecificParameters() portable: got %v, want %v", portable, wantPortable)
+	}
+
+	if want := []string{"listen", "servername"}; !reflect.DeepEqual(specific, want) {
+		t.Errorf("SplitSystemSpecificParameters() specific: got %v, want %v", specific, want)
+	}
+}
+
+func TestSplitSystemSpecificParametersBoundaries(t *testing.T) {
+	parameters := map[string]interface{}{
+		"pool":     "mysqldb_pool",
+		"upstream": "10.0.0.10:3306",
+		"backend":  "10.0.0.1:3306",
+		"host":     "db.example.com",
+	}
+
+	portable, specific := utils.SplitSystemSpecificParameters(parameters, []string{"db", "10.0.0.1"})
+
+	wantPortable := map[string]interface{}{
+		"pool":     "mysqldb_pool",
+		"upstream": "10.0.0.10:3306",
+	}
+
+	if !reflect.DeepEqual(portable, wantPortable) {
+		t.Errorf("SplitSystemSpecificParameters() portable: got %v, want %v", portable, wantPortable)
+	}
+
+	if want := []string{"backend", "host"}; !reflect.DeepEqual(specific, want) {
+		t.Errorf("SplitSystemSpecificParameters() specific: got %v, want %v", specific, want)
+	}
+}
+
+func TestDiffCookbooks(t *testing.T) {
+	a := utils.CookbookSet{
+		"php":     {"versions": []interface{}{"8.1", "8.2"}, "memory_limit": "256M"},
+		"nginx":   {"waf": true},
+		"varnish": {},
+	}
+
+	b := utils.CookbookSet{
+		"php":   {"versions": []interface{}{"8.2"}, "memory_limit": "128M"},
+		"nginx": {},
+		"redis": {},
+	}
+
+	defaults := map[string]map[string]string{
+		"php": {"memory_limit": "128M"},
+	}
+
+	want := []utils.CookbookDifference{
+		{CookbookType: "nginx", Parameter: "waf", A: "true", B: utils.CookbookDefault},
+		{CookbookType: "php", Parameter: "memory_limit", A: "256M", B: "128M"},
+		{CookbookType: "php", Parameter: "versions", A: "8.1,8.2", B: "8.2"},
+		{CookbookType: "redis", A: utils.CookbookMissing, B: "present"},
+		{CookbookType: "varnish", A: "present", B: utils.CookbookMissing},
+	}
+
+	if got := utils.DiffCookbooks(a, b, defaults); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffCookbooks():\ngot  %+v\nwant %+v", got, want)
+	}
+
+	// Explicitly setting a parameter to its default is not a difference.
+	b["php"] = map[string]interface{}{"versions": []interface{}{"8.1", "8.2"}}
+	a["php"] = map[string]interface{}{"versions": []interface{}{"8.1", "8.2"}, "memory_limit": "128M"}
+	for _, diff := range utils.DiffCookbooks(a, b, defaults) {
+		if diff.CookbookType == "php" {
+			t.Errorf("DiffCookbooks(): unexpected difference %+v", diff)
+		}
+	}
+}
+
+func TestLoadCookbookSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookbooks.yaml")
+	data := "cookbooks:\n  php:\n    versions: [\"8.1\", \"8.2\"]\n  nginx:\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := utils.LoadCookbookSet(path)
+	if err != nil {
+		t.Fatalf("LoadCookbookSet(): %s", err.Error())
+	}
+
+	if len(set) != 2 || set["nginx"] == nil || utils.CheckParameterString(set["php"]["versions"]) != "8.1,8.2" {
+		t.Errorf("LoadCookbookSet(): got %+v", set)
+	}
+
+	// Nested values have to be sent to the API as JSON.
+	data = "cookbooks:\n  nginx:\n    headers:\n      X-Frame-Options: DENY\n    upstreams:\n      - {name: app, port: 8080}\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err = utils.LoadCookbookSet(path)
+	if err != nil {
+		t.Fatalf("LoadCookbookSet(): %s", err.Error())
+	}
+
+	encoded, err := json.Marshal(set["nginx"])
+	if want := `{"headers":{"X-Frame-Options":"DENY"},"upstreams":[{"name":"app","port":8080}]}`; err != nil || string(encoded) != want {
+		t.Errorf("LoadCookbookSet() nested: got %s, %v, want %s", encoded, err, want)
+	}
+}
@@ -0,0 +1,501 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// Minimal DNS client, used to query authoritative nameservers directly.
+// Only what is needed to compare the answers against zone records is implemented:
+// a single question per query, with record data decoded to its zone file presentation form.
+// References:
+// * RFC 1035 (section 4): message format.
+// * RFC 6891 (section 6): EDNS(0) OPT record, to allow larger UDP responses.
+//
+
+// A resource record from the answer section of a DNS response.
+type DnsAnswer struct {
+	// Absolute, lower-cased owner name of the record.
+	Name  string
+	Class DnsClass
+	Ttl   RecordTtl
+	Type  RecordType
+	// Record data in zone file presentation form. Domain names in the data are absolute.
+	Data []string
+}
+
+type DnsResponse struct {
+	Rcode         DnsRcode
+	Authoritative bool
+	Answers       []DnsAnswer
+}
+
+type DnsRcode uint8
+
+const (
+	DnsRcodeNoError  DnsRcode = 0
+	DnsRcodeServFail DnsRcode = 2
+	DnsRcodeNXDomain DnsRcode = 3
+	DnsRcodeRefused  DnsRcode = 5
+)
+
+func (r DnsRcode) String() string {
+	switch r {
+	case DnsRcodeNoError:
+		return "NOERROR"
+	case DnsRcodeServFail:
+		return "SERVFAIL"
+	case DnsRcodeNXDomain:
+		return "NXDOMAIN"
+	case DnsRcodeRefused:
+		return "REFUSED"
+	default:
+		return fmt.Sprintf("RCODE%d", r)
+	}
+}
+
+// UDP payload size advertised with EDNS(0).
+const dnsUdpSize = 1232
+
+const dnsTypeOpt = 41
+
+// Get the answer record as a zone record, so it can be compared with records from zone files.
+func (a DnsAnswer) ZoneRecord() ZoneRecord {
+	return ZoneRecord{
+		Name:   a.Name,
+		Origin: ".",
+		Class:  a.Class,
+		Ttl:    &a.Ttl,
+		Type:   a.Type,
+		Data:   a.Data,
+	}
+}
+
+// Send a non-recursive query for a single name and type to a DNS server.
+// server must be a host:port pair. Truncated UDP responses are retried over TCP.
+func DnsQuery(server string, name string, recordType RecordType, timeout time.Duration) (*DnsResponse, error) {
+	// An unpredictable query ID makes spoofed answers harder.
+	idBytes := make([]byte, 2)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+
+	id := binary.BigEndian.Uint16(idBytes)
+	query, err := dnsBuildQuery(id, name, recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := dnsExchangeUdp(server, query, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	truncated, err := dnsIsTruncated(response)
+	if err != nil {
+		return nil, err
+	}
+
+	if truncated {
+		response, err = dnsExchangeTcp(server, query, timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dnsParseResponse(id, response)
+}
+
+func dnsExchangeUdp(server string, query []byte, timeout time.Duration) ([]byte, error) {
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	_, err = conn.Write(query)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 65535)
+	for {
+		read, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		// Ignore stray responses to other queries.
+		if read >= 2 && binary.BigEndian.Uint16(buf) == binary.BigEndian.Uint16(query) {
+			return buf[:read], nil
+		}
+	}
+}
+
+func dnsExchangeTcp(server string, query []byte, timeout time.Duration) ([]byte, error) {
+	conn, err := net.DialTimeout("tcp", server, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// TCP messages are prefixed with a two byte length.
+	msg := dnsAppendUint16(nil, uint16(len(query)))
+	_, err = conn.Write(append(msg, query...))
+	if err != nil {
+		return nil, err
+	}
+
+	var length [2]byte
+	_, err = io.ReadFull(conn, length[:])
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]byte, binary.BigEndian.Uint16(length[:]))
+	_, err = io.ReadFull(conn, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func dnsBuildQuery(id uint16, name string, recordType RecordType) ([]byte, error) {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	// Flags are all zero: standard query, no recursion desired.
+	binary.BigEndian.PutUint16(msg[4:], 1)  // QDCOUNT
+	binary.BigEndian.PutUint16(msg[10:], 1) // ARCOUNT, for the OPT record.
+
+	msg, err := dnsAppendName(msg, name)
+	if err != nil {
+		return nil, err
+	}
+
+	msg = dnsAppendUint16(msg, uint16(recordType))
+	msg = dnsAppendUint16(msg, uint16(DnsClassIN))
+
+	// OPT pseudo-record: root name, type, UDP payload size as class, zero TTL (extended flags) and no data.
+	msg = append(msg, 0)
+	msg = dnsAppendUint16(msg, dnsTypeOpt)
+	msg = dnsAppendUint16(msg, dnsUdpSize)
+	msg = append(msg, 0, 0, 0, 0, 0, 0)
+
+	return msg, nil
+}
+
+func dnsAppendUint16(msg []byte, value uint16) []byte {
+	return append(msg, byte(value>>8), byte(value))
+}
+
+func dnsAppendName(msg []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("invalid domain name: '%s'", name)
+			}
+
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+	}
+
+	return append(msg, 0), nil
+}
+
+func dnsIsTruncated(msg []byte) (bool, error) {
+	if len(msg) < 12 {
+		return false, errDnsShortMessage
+	}
+
+	return msg[2]&0x02 != 0, nil
+}
+
+var errDnsShortMessage = errors.New("DNS message is too short")
+
+func dnsParseResponse(id uint16, msg []byte) (*DnsResponse, error) {
+	if len(msg) < 12 {
+		return nil, errDnsShortMessage
+	}
+
+	if binary.BigEndian.Uint16(msg[0:]) != id {
+		return nil, errors.New("DNS response has wrong ID")
+	}
+
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&0x8000 == 0 {
+		return nil, errors.New("DNS message is not a response")
+	}
+
+	response := &DnsResponse{
+		Rcode:         DnsRcode(flags & 0x000f),
+		Authoritative: flags&0x0400 != 0,
+	}
+
+	qdCount := binary.BigEndian.Uint16(msg[4:])
+	anCount := binary.BigEndian.Uint16(msg[6:])
+
+	offset := 12
+	for i := 0; i < int(qdCount); i++ {
+		_, next, err := dnsReadName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		offset = next + 4
+	}
+
+	for i := 0; i < int(anCount); i++ {
+		name, next, err := dnsReadName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		if next+10 > len(msg) {
+			return nil, errDnsShortMessage
+		}
+
+		recordType := RecordType(binary.BigEndian.Uint16(msg[next:]))
+		class := DnsClass(binary.BigEndian.Uint16(msg[next+2:]))
+		ttl := RecordTtl(binary.BigEndian.Uint32(msg[next+4:]))
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		dataStart := next + 10
+		if dataStart+length > len(msg) {
+			return nil, errDnsShortMessage
+		}
+
+		data, err := dnsReadData(msg, dataStart, length, recordType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %v record data for '%s': %s", recordType, name, err.Error())
+		}
+
+		response.Answers = append(response.Answers, DnsAnswer{
+			Name:  name,
+			Class: class,
+			Ttl:   ttl,
+			Type:  recordType,
+			Data:  data,
+		})
+
+		offset = dataStart + length
+	}
+
+	return response, nil
+}
+
+// Read a (possibly compressed) domain name.
+// Returns the absolute, lower-cased name and the offset right after the name.
+func dnsReadName(msg []byte, offset int) (string, int, error) {
+	labels := []string{}
+	end := -1
+	// Limit the amount of pointers followed, to protect against loops.
+	for jumps := 0; jumps < 64; {
+		if offset >= len(msg) {
+			return "", 0, errDnsShortMessage
+		}
+
+		length := int(msg[offset])
+		switch {
+		case length == 0:
+			if end == -1 {
+				end = offset + 1
+			}
+
+			return strings.ToLower(strings.Join(labels, ".")) + ".", end, nil
+		case length&0xc0 == 0xc0:
+			if offset+1 >= len(msg) {
+				return "", 0, errDnsShortMessage
+			}
+
+			if end == -1 {
+				end = offset + 2
+			}
+
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) & 0x3fff)
+			jumps += 1
+		default:
+			if offset+1+length > len(msg) {
+				return "", 0, errDnsShortMessage
+			}
+
+			labels = append(labels, dnsEscapeLabel(msg[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+	}
+
+	return "", 0, errors.New("too many compression pointers in domain name")
+}
+
+// Escape a label for presentation form.
+func dnsEscapeLabel(label []byte) string {
+	var buf strings.Builder
+	for _, chr := range label {
+		switch {
+		case chr == '.' || chr == '\\' || chr == '"' || chr == ';' || chr == '(' || chr == ')':
+			buf.WriteByte('\\')
+			buf.WriteByte(chr)
+		case chr <= ' ' || chr >= 0x7f:
+			fmt.Fprintf(&buf, "\\%03d", chr)
+		default:
+			buf.WriteByte(chr)
+		}
+	}
+
+	return buf.String()
+}
+
+// Decode record data to its presentation form.
+func dnsReadData(msg []byte, offset int, length int, recordType RecordType) ([]string, error) {
+	data := msg[offset : offset+length]
+	end := offset + length
+
+	// Reads a name embedded in the data, which may use compression pointers into the rest of the message.
+	readName := func(at int) (string, int, error) {
+		name, next, err := dnsReadName(msg, at)
+		if err == nil && next > end {
+			err = errDnsShortMessage
+		}
+
+		return name, next, err
+	}
+
+	uint16At := func(at int) string {
+		return strconv.Itoa(int(binary.BigEndian.Uint16(data[at:])))
+	}
+
+	switch recordType {
+	case RecordTypeA:
+		if length != net.IPv4len {
+			return nil, errDnsShortMessage
+		}
+
+		return []string{net.IP(data).String()}, nil
+
+	case RecordTypeAAAA:
+		if length != net.IPv6len {
+			return nil, errDnsShortMessage
+		}
+
+		return []string{net.IP(data).String()}, nil
+
+	case RecordTypeNS, RecordTypeCNAME:
+		name, _, err := readName(offset)
+		return []string{name}, err
+
+	case RecordTypeMX:
+		if length < 3 {
+			return nil, errDnsShortMessage
+		}
+
+		name, _, err := readName(offset + 2)
+		return []string{uint16At(0), name}, err
+
+	case RecordTypeSRV:
+		if length < 7 {
+			return nil, errDnsShortMessage
+		}
+
+		name, _, err := readName(offset + 6)
+		return []string{uint16At(0), uint16At(2), uint16At(4), name}, err
+
+	case RecordTypeTXT:
+		strs := []string{}
+		for i := 0; i < length; {
+			strLength := int(data[i])
+			if i+1+strLength > length {
+				return nil, errDnsShortMessage
+			}
+
+			strs = append(strs, string(data[i+1:i+1+strLength]))
+			i += 1 + strLength
+		}
+
+		return strs, nil
+
+	case RecordTypeCAA:
+		if length < 2 || 2+int(data[1]) > length {
+			return nil, errDnsShortMessage
+		}
+
+		tagEnd := 2 + int(data[1])
+		return []string{strconv.Itoa(int(data[0])), string(data[2:tagEnd]), string(data[tagEnd:])}, nil
+
+	case RecordTypeTLSA:
+		if length < 3 {
+			return nil, errDnsShortMessage
+		}
+
+		return []string{
+			strconv.Itoa(int(data[0])),
+			strconv.Itoa(int(data[1])),
+			strconv.Itoa(int(data[2])),
+			hex.EncodeToString(data[3:]),
+		}, nil
+
+	case RecordTypeDS:
+		if length < 4 {
+			return nil, errDnsShortMessage
+		}
+
+		return []string{
+			uint16At(0),
+			strconv.Itoa(int(data[2])),
+			strconv.Itoa(int(data[3])),
+			hex.EncodeToString(data[4:]),
+		}, nil
+
+	case RecordTypeDNSKEY:
+		if length < 4 {
+			return nil, errDnsShortMessage
+		}
+
+		return []string{
+			uint16At(0),
+			strconv.Itoa(int(data[2])),
+			strconv.Itoa(int(data[3])),
+			base64.StdEncoding.EncodeToString(data[4:]),
+		}, nil
+
+	case RecordTypeSOA:
+		mname, next, err := readName(offset)
+		if err != nil {
+			return nil, err
+		}
+
+		rname, next, err := readName(next)
+		if err != nil {
+			return nil, err
+		}
+
+		if next+20 > end {
+			return nil, errDnsShortMessage
+		}
+
+		result := []string{mname, rname}
+		for i := 0; i < 5; i++ {
+			result = append(result, strconv.FormatUint(uint64(binary.BigEndian.Uint32(msg[next+i*4:])), 10))
+		}
+
+		return result, nil
+
+	default:
+		// RFC 3597 generic record data.
+		return []string{"\\#", strconv.Itoa(length), hex.EncodeToString(data)}, nil
+	}
+}
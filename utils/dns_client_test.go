@@ -0,0 +1,169 @@
+package utils_test
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/level27/lvl/utils"
+)
+
+func TestDnsQuery(t *testing.T) {
+	server := startTestDnsServer(t, false)
+
+	response, err := utils.DnsQuery(server, "example.com.", utils.RecordTypeMX, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkTestDnsResponse(t, response)
+}
+
+func TestDnsQueryTruncated(t *testing.T) {
+	server := startTestDnsServer(t, true)
+
+	response, err := utils.DnsQuery(server, "example.com.", utils.RecordTypeMX, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkTestDnsResponse(t, response)
+}
+
+func checkTestDnsResponse(t *testing.T, response *utils.DnsResponse) {
+	if !response.Authoritative || response.Rcode != utils.DnsRcodeNoError {
+		t.Fatalf("Unexpected response header: %+v", response)
+	}
+
+	expected := []utils.DnsAnswer{
+		{Name: "example.com.", Class: utils.DnsClassIN, Ttl: 3600, Type: utils.RecordTypeMX, Data: []string{"10", "mail.example.com."}},
+		{Name: "example.com.", Class: utils.DnsClassIN, Ttl: 3600, Type: utils.RecordTypeTXT, Data: []string{"v=spf1 ", "mx -all"}},
+		{Name: "example.com.", Class: utils.DnsClassIN, Ttl: 3600, Type: utils.RecordTypeCAA, Data: []string{"0", "issue", "letsencrypt.org"}},
+		{Name: "mail.example.com.", Class: utils.DnsClassIN, Ttl: 3600, Type: utils.RecordTypeA, Data: []string{"192.0.2.1"}},
+	}
+
+	if !reflect.DeepEqual(response.Answers, expected) {
+		t.Fatalf("Unexpected answers.\nExpected: %+v\nGot:      %+v", expected, response.Answers)
+	}
+
+	// Answers must compare equal to the same records read from a zone file.
+	zone := utils.ReadZone(strings.NewReader(`
+@    MX   10 mail
+@    TXT  "v=spf1 mx -all"
+@    CAA  0 ISSUE "letsencrypt.org"
+mail A    192.0.2.1
+`), "example.com.")
+
+	for i, record := range zone.Records {
+		if record.DataKey() != response.Answers[i].ZoneRecord().DataKey() {
+			t.Errorf("Data of record %d does not match: '%s' vs '%s'", i, record.DataKey(), response.Answers[i].ZoneRecord().DataKey())
+		}
+	}
+}
+
+// Start a DNS server that gives the same answers to every query.
+// If truncate is set, UDP responses are truncated so the client has to retry over TCP.
+func startTestDnsServer(t *testing.T, truncate bool) string {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { tcpListener.Close() })
+
+	udpConn, err := net.ListenPacket("udp", tcpListener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { udpConn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			read, addr, err := udpConn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			udpConn.WriteTo(makeTestDnsResponse(buf[:read], truncate), addr)
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := tcpListener.Accept()
+			if err != nil {
+				return
+			}
+
+			var length [2]byte
+			io.ReadFull(conn, length[:])
+			query := make([]byte, binary.BigEndian.Uint16(length[:]))
+			io.ReadFull(conn, query)
+
+			response := makeTestDnsResponse(query, false)
+			binary.BigEndian.PutUint16(length[:], uint16(len(response)))
+			conn.Write(append(length[:], response...))
+			conn.Close()
+		}
+	}()
+
+	return tcpListener.Addr().String()
+}
+
+func makeTestDnsResponse(query []byte, truncate bool) []byte {
+	// Copy the header and question section (the question is at offset 12, "example.com." is 13 bytes).
+	questionEnd := 12 + 13 + 4
+	msg := append([]byte{}, query[:questionEnd]...)
+
+	flags := uint16(0x8400) // QR, AA
+	if truncate {
+		flags |= 0x0200
+	}
+
+	binary.BigEndian.PutUint16(msg[2:], flags)
+	binary.BigEndian.PutUint16(msg[10:], 0) // ARCOUNT
+
+	if truncate {
+		return msg
+	}
+
+	binary.BigEndian.PutUint16(msg[6:], 4) // ANCOUNT
+
+	// Pointer to "example.com." in the question.
+	example := []byte{0xc0, 12}
+
+	answer := func(name []byte, recordType utils.RecordType, data []byte) {
+		msg = append(msg, name...)
+		msg = appendTestUint(msg, 2, uint32(recordType))
+		msg = appendTestUint(msg, 2, uint32(utils.DnsClassIN))
+		msg = appendTestUint(msg, 4, 3600)
+		msg = appendTestUint(msg, 2, uint32(len(data)))
+		msg = append(msg, data...)
+	}
+
+	// MX 10 mail.example.com., with the target name compressed.
+	mxStart := len(msg)
+	answer(example, utils.RecordTypeMX, append([]byte{0, 10, 4, 'm', 'a', 'i', 'l'}, example...))
+	mailName := []byte{0xc0, byte(mxStart + len(example) + 10 + 2)}
+
+	answer(example, utils.RecordTypeTXT, []byte("\x07v=spf1 \x07mx -all"))
+	answer(example, utils.RecordTypeCAA, []byte("\x00\x05issueletsencrypt.org"))
+	answer(mailName, utils.RecordTypeA, []byte{192, 0, 2, 1})
+
+	return msg
+}
+
+// Append a big-endian integer of the given size in bytes.
+func appendTestUint(msg []byte, size int, value uint32) []byte {
+	for i := size - 1; i >= 0; i-- {
+		msg = append(msg, byte(value>>(8*i)))
+	}
+
+	return msg
+}
@@ -0,0 +1,313 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+//
+// DNSSEC key and delegation signer (DS) records.
+// References:
+// * RFC 4034 (section 2, 5):   DNSKEY and DS record format, DS digest calculation.
+// * RFC 4034 (appendix B):     key tag calculation.
+// * RFC 4509, RFC 6605:        SHA-256 and SHA-384 DS digests.
+// * RFC 8624 (section 3.1):    algorithms that should (not) be used.
+//
+
+// DNSKEY flag marking a key as zone key.
+const DnskeyFlagZone uint16 = 0x0100
+
+// DNSKEY flag marking a key as secure entry point, i.e. the key signing key that DS records refer to.
+const DnskeyFlagSep uint16 = 0x0001
+
+type DnssecAlgorithm uint8
+
+var dnssecAlgorithmNames = map[DnssecAlgorithm]string{
+	1:  "RSAMD5",
+	3:  "DSA",
+	5:  "RSASHA1",
+	6:  "DSA-NSEC3-SHA1",
+	7:  "RSASHA1-NSEC3-SHA1",
+	8:  "RSASHA256",
+	10: "RSASHA512",
+	12: "ECC-GOST",
+	13: "ECDSAP256SHA256",
+	14: "ECDSAP384SHA384",
+	15: "ED25519",
+	16: "ED448",
+}
+
+// Algorithms that must not be used for signing anymore.
+var dnssecDeprecatedAlgorithms = []DnssecAlgorithm{1, 3, 6, 12}
+
+func (a DnssecAlgorithm) String() string {
+	if name, ok := dnssecAlgorithmNames[a]; ok {
+		return name
+	}
+
+	return strconv.Itoa(int(a))
+}
+
+type DsDigestType uint8
+
+const (
+	DsDigestSha1   DsDigestType = 1
+	DsDigestSha256 DsDigestType = 2
+	DsDigestSha384 DsDigestType = 4
+)
+
+var dsDigestTypeMap = map[string]DsDigestType{
+	"SHA-1":   DsDigestSha1,
+	"SHA-256": DsDigestSha256,
+	"SHA-384": DsDigestSha384,
+}
+
+var dsDigestTypeMapReverse = reverseMap(dsDigestTypeMap)
+
+func (t DsDigestType) String() string {
+	if name, ok := dsDigestTypeMapReverse[t]; ok {
+		return name
+	}
+
+	return strconv.Itoa(int(t))
+}
+
+// Parse a DS digest type, either by number or by name (e.g. "2", "sha256" or "SHA-256").
+func ParseDsDigestType(value string) (DsDigestType, error) {
+	if number, err := strconv.ParseUint(value, 10, 8); err == nil {
+		return DsDigestType(number), nil
+	}
+
+	normalized := strings.ToUpper(value)
+	if !strings.Contains(normalized, "-") && strings.HasPrefix(normalized, "SHA") {
+		normalized = "SHA-" + normalized[3:]
+	}
+
+	if digestType, ok := dsDigestTypeMap[normalized]; ok {
+		return digestType, nil
+	}
+
+	return 0, fmt.Errorf("unknown DS digest type: '%s'", value)
+}
+
+// Create the hash for a digest type. Returns nil if the digest type is not known.
+func (t DsDigestType) hash() hash.Hash {
+	switch t {
+	case DsDigestSha1:
+		return sha1.New()
+	case DsDigestSha256:
+		return sha256.New()
+	case DsDigestSha384:
+		return sha512.New384()
+	}
+
+	return nil
+}
+
+// A DNSKEY record.
+type Dnskey struct {
+	Flags     uint16
+	Protocol  uint8
+	Algorithm DnssecAlgorithm
+	PublicKey []byte
+}
+
+// Parse the data of a DNSKEY record: <flags> <protocol> <algorithm> <public key>.
+// The base64 public key may be split over multiple items.
+func ParseDnskey(data []string) (Dnskey, error) {
+	if len(data) < 4 {
+		return Dnskey{}, fmt.Errorf("DNSKEY record must have flags, protocol, algorithm and public key")
+	}
+
+	flags, err := strconv.ParseUint(data[0], 10, 16)
+	if err != nil {
+		return Dnskey{}, fmt.Errorf("invalid DNSKEY flags: '%s'", data[0])
+	}
+
+	protocol, err := strconv.ParseUint(data[1], 10, 8)
+	if err != nil || protocol != 3 {
+		return Dnskey{}, fmt.Errorf("invalid DNSKEY protocol: '%s', must be 3", data[1])
+	}
+
+	algorithm, err := strconv.ParseUint(data[2], 10, 8)
+	if err != nil {
+		return Dnskey{}, fmt.Errorf("invalid DNSKEY algorithm: '%s'", data[2])
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(strings.Join(data[3:], ""))
+	if err != nil || len(publicKey) == 0 {
+		return Dnskey{}, fmt.Errorf("DNSKEY public key is not valid base64")
+	}
+
+	return Dnskey{
+		Flags:     uint16(flags),
+		Protocol:  uint8(protocol),
+		Algorithm: DnssecAlgorithm(algorithm),
+		PublicKey: publicKey,
+	}, nil
+}
+
+// Whether this key is a key signing key, i.e. has the secure entry point flag set.
+func (k Dnskey) IsKsk() bool {
+	return k.Flags&DnskeyFlagSep != 0
+}
+
+// Get the DNSKEY record data in presentation form.
+func (k Dnskey) Data() []string {
+	return []string{
+		strconv.Itoa(int(k.Flags)),
+		strconv.Itoa(int(k.Protocol)),
+		strconv.Itoa(int(k.Algorithm)),
+		base64.StdEncoding.EncodeToString(k.PublicKey),
+	}
+}
+
+// Get the record data in wire format.
+func (k Dnskey) rdata() []byte {
+	rdata := dnsAppendUint16(nil, k.Flags)
+	rdata = append(rdata, k.Protocol, uint8(k.Algorithm))
+	return append(rdata, k.PublicKey...)
+}
+
+// Calculate the key tag used to refer to this key from DS records and signatures.
+func (k Dnskey) KeyTag() uint16 {
+	var acc uint32
+	for i, b := range k.rdata() {
+		if i&1 == 0 {
+			acc += uint32(b) << 8
+		} else {
+			acc += uint32(b)
+		}
+	}
+
+	acc += acc >> 16 & 0xFFFF
+	return uint16(acc & 0xFFFF)
+}
+
+// Calculate the DS record for this key, owned by the given (absolute) name.
+func (k Dnskey) Ds(owner string, digestType DsDigestType) (Ds, error) {
+	h := digestType.hash()
+	if h == nil {
+		return Ds{}, fmt.Errorf("unsupported DS digest type: %v", digestType)
+	}
+
+	// The owner name is hashed in canonical form, i.e. lower-case.
+	name, err := dnsAppendName(nil, strings.ToLower(owner))
+	if err != nil {
+		return Ds{}, err
+	}
+
+	h.Write(name)
+	h.Write(k.rdata())
+
+	return Ds{
+		KeyTag:     k.KeyTag(),
+		Algorithm:  k.Algorithm,
+		DigestType: digestType,
+		Digest:     h.Sum(nil),
+	}, nil
+}
+
+// A DS record.
+type Ds struct {
+	KeyTag     uint16
+	Algorithm  DnssecAlgorithm
+	DigestType DsDigestType
+	Digest     []byte
+}
+
+// Parse the data of a DS record: <key tag> <algorithm> <digest type> <digest>.
+// The hexadecimal digest may be split over multiple items.
+func ParseDs(data []string) (Ds, error) {
+	if len(data) < 4 {
+		return Ds{}, fmt.Errorf("DS record must have key tag, algorithm, digest type and digest")
+	}
+
+	keyTag, err := strconv.ParseUint(data[0], 10, 16)
+	if err != nil {
+		return Ds{}, fmt.Errorf("invalid DS key tag: '%s'", data[0])
+	}
+
+	algorithm, err := strconv.ParseUint(data[1], 10, 8)
+	if err != nil {
+		return Ds{}, fmt.Errorf("invalid DS algorithm: '%s'", data[1])
+	}
+
+	digestType, err := strconv.ParseUint(data[2], 10, 8)
+	if err != nil {
+		return Ds{}, fmt.Errorf("invalid DS digest type: '%s'", data[2])
+	}
+
+	digest, err := hex.DecodeString(strings.Join(data[3:], ""))
+	if err != nil {
+		return Ds{}, fmt.Errorf("DS digest is not valid hexadecimal")
+	}
+
+	return Ds{
+		KeyTag:     uint16(keyTag),
+		Algorithm:  DnssecAlgorithm(algorithm),
+		DigestType: DsDigestType(digestType),
+		Digest:     digest,
+	}, nil
+}
+
+// Get the DS record data in presentation form.
+func (d Ds) Data() []string {
+	return []string{
+		strconv.Itoa(int(d.KeyTag)),
+		strconv.Itoa(int(d.Algorithm)),
+		strconv.Itoa(int(d.DigestType)),
+		hex.EncodeToString(d.Digest),
+	}
+}
+
+func (d Ds) String() string {
+	return strings.Join(d.Data(), " ")
+}
+
+// Check a DS record for problems that would break the delegation.
+// Returns a list of errors, empty if the record is valid.
+func (d Ds) Validate() []string {
+	errs := []string{}
+	if _, ok := dnssecAlgorithmNames[d.Algorithm]; !ok {
+		errs = append(errs, fmt.Sprintf("unknown DS algorithm: %d", d.Algorithm))
+	} else if dnssecAlgorithmDeprecated(d.Algorithm) {
+		errs = append(errs, fmt.Sprintf("DS algorithm %v must not be used", d.Algorithm))
+	}
+
+	h := d.DigestType.hash()
+	if h == nil {
+		errs = append(errs, fmt.Sprintf("unknown DS digest type: %d", d.DigestType))
+	} else if len(d.Digest) != h.Size() {
+		errs = append(errs, fmt.Sprintf("DS %v digest must be %d bytes, got %d", d.DigestType, h.Size(), len(d.Digest)))
+	}
+
+	return errs
+}
+
+// Check whether this DS record refers to the given key, owned by the given (absolute) name.
+func (d Ds) Matches(owner string, key Dnskey) bool {
+	if d.KeyTag != key.KeyTag() || d.Algorithm != key.Algorithm {
+		return false
+	}
+
+	expected, err := key.Ds(owner, d.DigestType)
+	return err == nil && hex.EncodeToString(expected.Digest) == hex.EncodeToString(d.Digest)
+}
+
+func dnssecAlgorithmDeprecated(algorithm DnssecAlgorithm) bool {
+	for _, deprecated := range dnssecDeprecatedAlgorithms {
+		if algorithm == deprecated {
+			return true
+		}
+	}
+
+	return false
+}
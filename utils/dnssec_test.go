@@ -0,0 +1,121 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/level27/lvl/utils"
+)
+
+// Examples from RFC 4034 (section 5.4), RFC 4509 (section 2.3) and RFC 6605 (section 6).
+var testDnssecKeys = []struct {
+	owner  string
+	dnskey string
+	ds     []string
+}{
+	{
+		"dskey.example.com.",
+		"256 3 5 AQOeiiR0GOMYkDshWoSKz9XzfwJr1AYtsmx3TGkJaNXVbfi/2pHm822aJ5iI9BMzNXxeYCmZDRD99WYwYqUSdjMmmAphXdvxegXd/M5+X7OrzKBaMbCVdFLUUh6DhweJBjEVv5f2wwjM9XzcnOf+EPbtG9DMBmADjFDc2w/rljwvFw==",
+		[]string{
+			"60485 5 1 2BB183AF5F22588179A53B0A98631FAD1A292118",
+			"60485 5 2 D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A",
+		},
+	},
+	{
+		"example.net.",
+		"257 3 13 GojIhhXUN/u4v54ZQqGSnyhWJwaubCvTmeexv7bR6edbkrSqQpF64cYbcB7wNcP+e+MAnLr+Wi9xMWyQLc8NAA==",
+		[]string{
+			"55648 13 2 b4c8c1fe2e7477127b27115656ad6256f424625bf5c1e2770ce6d6e37df61d17",
+		},
+	},
+	{
+		"example.net.",
+		"257 3 14 xKYaNhWdGOfJ+nPrL8/arkwf2EY3MDJ+SErKivBVSum1w/egsXvSADtNJhyem5RCOpgQ6K8X1DRSEkrbYQ+OB+v8/uX45NBwY8rp65F6Glur8I/mlVNgF6W/qTI37m40",
+		[]string{
+			"10771 14 4 72d7b62976ce06438e9c0bf319013cf801f09ecc84b8d7e9495f27e305c6a9b0563a9b5f4d288405c3008a946df983d6",
+		},
+	},
+}
+
+func TestDnssecDs(t *testing.T) {
+	for _, test := range testDnssecKeys {
+		key, err := utils.ParseDnskey(strings.Fields(test.dnskey))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, dsText := range test.ds {
+			expected, err := utils.ParseDs(strings.Fields(dsText))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if key.KeyTag() != expected.KeyTag {
+				t.Errorf("Expected key tag %d, got %d", expected.KeyTag, key.KeyTag())
+			}
+
+			// Owner names are case-insensitive.
+			ds, err := key.Ds(strings.ToUpper(test.owner), expected.DigestType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ds.String() != strings.ToLower(dsText) {
+				t.Errorf("Unexpected DS for %s.\nExpected: %s\nGot:      %s", test.owner, strings.ToLower(dsText), ds.String())
+			}
+
+			if !expected.Matches(test.owner, key) {
+				t.Errorf("DS %s does not match its key", dsText)
+			}
+
+			if expected.Matches("other.example.", key) {
+				t.Errorf("DS %s matches a key with a different owner", dsText)
+			}
+		}
+	}
+}
+
+func TestDnssecDsValidate(t *testing.T) {
+	tests := []struct {
+		ds       string
+		expected []string
+	}{
+		{"60485 8 2 D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A", []string{}},
+		{"60485 8 2 D4B7D520", []string{"DS SHA-256 digest must be 32 bytes, got 4"}},
+		{"60485 3 2 D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A", []string{"DS algorithm DSA must not be used"}},
+		{"60485 200 9 D4B7", []string{"unknown DS algorithm: 200", "unknown DS digest type: 9"}},
+	}
+
+	for _, test := range tests {
+		ds, err := utils.ParseDs(strings.Fields(test.ds))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		errs := ds.Validate()
+		if strings.Join(errs, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("Unexpected errors for '%s'.\nExpected: %v\nGot:      %v", test.ds, test.expected, errs)
+		}
+	}
+
+	if _, err := utils.ParseDs([]string{"60485", "8", "2", "not-hex"}); err == nil {
+		t.Errorf("Expected error for invalid digest")
+	}
+}
+
+func TestParseDsDigestType(t *testing.T) {
+	for value, expected := range map[string]utils.DsDigestType{
+		"2":       utils.DsDigestSha256,
+		"sha256":  utils.DsDigestSha256,
+		"SHA-384": utils.DsDigestSha384,
+	} {
+		digestType, err := utils.ParseDsDigestType(value)
+		if err != nil || digestType != expected {
+			t.Errorf("Expected %v for '%s', got %v (%v)", expected, value, digestType, err)
+		}
+	}
+
+	if _, err := utils.ParseDsDigestType("md5"); err == nil {
+		t.Errorf("Expected error for unknown digest type")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package utils
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Unix-specific code for locking files, see LockMaintenanceSchedule.
+
+// Try to take an exclusive lock on an open file without waiting.
+// Returns false if another process holds the lock. The lock is released when the file is closed.
+func tryLockFile(file *os.File) (bool, error) {
+	err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// States of a maintenance window.
+const (
+	// Maintenance has not started yet.
+	MaintenancePlanned = "planned"
+	// Maintenance has been started on the systems.
+	MaintenanceActive = "active"
+	// Maintenance has been started and stopped again.
+	MaintenanceDone = "done"
+	// The window ended before maintenance could be started, e.g. because the scheduler wasn't running.
+	MaintenanceMissed = "missed"
+	// The window was cancelled by the user.
+	MaintenanceCancelled = "cancelled"
+)
+
+// Actions the scheduler has to take on a maintenance window, see MaintenanceWindow.Due.
+const (
+	MaintenanceActionNone  = ""
+	MaintenanceActionStart = "start"
+	MaintenanceActionStop  = "stop"
+	MaintenanceActionMiss  = "miss"
+)
+
+// A system maintenance is planned for.
+type MaintenanceSystem struct {
+	ID   int    `yaml:"id" json:"id"`
+	Name string `yaml:"name" json:"name"`
+}
+
+// A planned period of maintenance on a set of systems.
+type MaintenanceWindow struct {
+	ID      int                 `yaml:"id" json:"id"`
+	Systems []MaintenanceSystem `yaml:"systems" json:"systems"`
+	Start   time.Time           `yaml:"start" json:"start"`
+	End     time.Time           `yaml:"end" json:"end"`
+	State   string              `yaml:"state" json:"state"`
+	// Errors from the last time the scheduler acted on the window.
+	Error string `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// Get the action to take on a maintenance window at a given time.
+func (w MaintenanceWindow) Due(now time.Time) string {
+	switch w.State {
+	case MaintenancePlanned:
+		if !now.Before(w.End) {
+			return MaintenanceActionMiss
+		}
+
+		if !now.Before(w.Start) {
+			return MaintenanceActionStart
+		}
+	case MaintenanceActive:
+		if !now.Before(w.End) {
+			return MaintenanceActionStop
+		}
+	}
+
+	return MaintenanceActionNone
+}
+
+// Get the amount of minutes left in a maintenance window, rounded up.
+// This is the duration to start maintenance on a system with, so it ends by itself even if the scheduler doesn't stop it.
+func (w MaintenanceWindow) MinutesLeft(now time.Time) int32 {
+	left := w.End.Sub(now)
+	if left <= 0 {
+		return 0
+	}
+
+	return int32(math.Ceil(left.Minutes()))
+}
+
+// Whether a maintenance window still has to start or stop.
+func (w MaintenanceWindow) Pending() bool {
+	return w.State == MaintenancePlanned || w.State == MaintenanceActive
+}
+
+// Layouts accepted by ParseMaintenanceTime, besides RFC 3339.
+var maintenanceTimeLayouts = []string{
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+}
+
+// Parse the start time of a maintenance window, such as 2026-11-01T02:00.
+// Times without a time zone are in the given location.
+func ParseMaintenanceTime(value string, loc *time.Location) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	for _, layout := range maintenanceTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time: '%s'. Expected a time like 2026-11-01T02:00", value)
+}
+
+// Load maintenance windows from a schedule file. A missing file is an empty schedule.
+func LoadMaintenanceSchedule(path string) ([]MaintenanceWindow, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []MaintenanceWindow{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	windows := []MaintenanceWindow{}
+	if err := yaml.Unmarshal(data, &windows); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %s", path, err.Error())
+	}
+
+	return windows, nil
+}
+
+// Save maintenance windows to a schedule file.
+// The file is replaced at once, so a scheduler reading it never sees a partial write.
+func SaveMaintenanceSchedule(path string, windows []MaintenanceWindow) error {
+	data, err := yaml.Marshal(windows)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// A temporary file in the same directory, so the rename stays on one filesystem.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// How long LockMaintenanceSchedule waits for another lvl to release the schedule.
+const maintenanceLockTimeout = 30 * time.Second
+
+// Take an exclusive lock on a schedule file, so a scheduler and a user changing the schedule don't overwrite each other.
+// The lock has to be held from loading the schedule until it is saved. Call the returned function to release it.
+// This is a lock held by the OS on a file next to the schedule, so it is released even if lvl doesn't exit cleanly.
+func LockMaintenanceSchedule(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	// The lock file is left in place, removing it would let two processes lock different files.
+	file, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(maintenanceLockTimeout)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		if locked {
+			return func() { file.Close() }, nil
+		}
+
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("maintenance schedule is locked by another lvl")
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Get the ID for a new maintenance window.
+func NextMaintenanceID(windows []MaintenanceWindow) int {
+	id := 0
+	for _, window := range windows {
+		if window.ID > id {
+			id = window.ID
+		}
+	}
+
+	return id + 1
+}
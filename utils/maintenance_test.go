@@ -0,0 +1,140 @@
+package utils_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/level27/lvl/utils"
+)
+
+func TestMaintenanceDue(t *testing.T) {
+	start := time.Date(2026, 11, 1, 2, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+
+	tests := []struct {
+		state string
+		now   time.Time
+		want  string
+	}{
+		{utils.MaintenancePlanned, start.Add(-time.Minute), utils.MaintenanceActionNone},
+		{utils.MaintenancePlanned, start, utils.MaintenanceActionStart},
+		{utils.MaintenancePlanned, start.Add(time.Hour), utils.MaintenanceActionStart},
+		{utils.MaintenancePlanned, end, utils.MaintenanceActionMiss},
+		{utils.MaintenanceActive, start.Add(time.Hour), utils.MaintenanceActionNone},
+		{utils.MaintenanceActive, end, utils.MaintenanceActionStop},
+		{utils.MaintenanceDone, end.Add(time.Hour), utils.MaintenanceActionNone},
+		{utils.MaintenanceCancelled, start, utils.MaintenanceActionNone},
+	}
+
+	for _, test := range tests {
+		window := utils.MaintenanceWindow{Start: start, End: end, State: test.state}
+		if got := window.Due(test.now); got != test.want {
+			t.Errorf("Due() in state %s at %s: got '%s', want '%s'", test.state, test.now, got, test.want)
+		}
+	}
+}
+
+func TestMaintenanceMinutesLeft(t *testing.T) {
+	start := time.Date(2026, 11, 1, 2, 0, 0, 0, time.UTC)
+	window := utils.MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour)}
+
+	tests := []struct {
+		now  time.Time
+		want int32
+	}{
+		{start, 120},
+		{start.Add(30 * time.Second), 120},
+		{start.Add(119 * time.Minute), 1},
+		{start.Add(3 * time.Hour), 0},
+	}
+
+	for _, test := range tests {
+		if got := window.MinutesLeft(test.now); got != test.want {
+			t.Errorf("MinutesLeft() at %s: got %d, want %d", test.now, got, test.want)
+		}
+	}
+}
+
+func TestParseMaintenanceTime(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	want := time.Date(2026, 11, 1, 2, 0, 0, 0, loc)
+
+	for _, value := range []string{"2026-11-01T02:00", "2026-11-01 02:00", "2026-11-01T02:00:00", "2026-11-01T01:00:00Z"} {
+		got, err := utils.ParseMaintenanceTime(value, loc)
+		if err != nil {
+			t.Errorf("ParseMaintenanceTime(%s): %s", value, err.Error())
+		} else if !got.Equal(want) {
+			t.Errorf("ParseMaintenanceTime(%s): got %s, want %s", value, got, want)
+		}
+	}
+
+	if _, err := utils.ParseMaintenanceTime("tomorrow", loc); err == nil {
+		t.Errorf("ParseMaintenanceTime(tomorrow): expected error")
+	}
+}
+
+func TestMaintenanceSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "maintenance.yaml")
+
+	windows, err := utils.LoadMaintenanceSchedule(path)
+	if err != nil || len(windows) != 0 {
+		t.Fatalf("LoadMaintenanceSchedule() on missing file: got %v, %v", windows, err)
+	}
+
+	start := time.Date(2026, 11, 1, 2, 0, 0, 0, time.UTC)
+	windows = append(windows, utils.MaintenanceWindow{
+		ID:      utils.NextMaintenanceID(windows),
+		Systems: []utils.MaintenanceSystem{{ID: 1234, Name: "web1"}},
+		Start:   start,
+		End:     start.Add(2 * time.Hour),
+		State:   utils.MaintenancePlanned,
+	})
+
+	if err := utils.SaveMaintenanceSchedule(path, windows); err != nil {
+		t.Fatalf("SaveMaintenanceSchedule(): %s", err.Error())
+	}
+
+	loaded, err := utils.LoadMaintenanceSchedule(path)
+	if err != nil {
+		t.Fatalf("LoadMaintenanceSchedule(): %s", err.Error())
+	}
+
+	if len(loaded) != 1 || loaded[0].ID != 1 || loaded[0].Systems[0].Name != "web1" || !loaded[0].End.Equal(windows[0].End) {
+		t.Errorf("LoadMaintenanceSchedule(): got %+v, want %+v", loaded, windows)
+	}
+
+	if id := utils.NextMaintenanceID(loaded); id != 2 {
+		t.Errorf("NextMaintenanceID(): got %d, want 2", id)
+	}
+}
+
+func TestLockMaintenanceSchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lvl", "maintenance.yaml")
+
+	unlock, err := utils.LockMaintenanceSchedule(path)
+	if err != nil {
+		t.Fatalf("LockMaintenanceSchedule(): %s", err.Error())
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		unlockSecond, err := utils.LockMaintenanceSchedule(path)
+		if err != nil {
+			t.Errorf("LockMaintenanceSchedule() after unlock: %s", err.Error())
+		} else {
+			unlockSecond()
+		}
+
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatalf("LockMaintenanceSchedule(): got the lock while it was held")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	unlock()
+	<-locked
+}
@@ -3,7 +3,9 @@ package utils
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
@@ -16,14 +18,12 @@ func MakeTemplateHelpers(t *template.Template) template.FuncMap {
 	return template.FuncMap{
 		// Formats a currenct + price pair such as "EUR", "100" -> 1.00 €.
 		"formatCurrency": func(currency string, price string) string {
-			switch currency {
-			case "EUR":
-				beforeDecimal := price[:len(price)-2]
-				afterDecimal := price[len(price)-2:]
-				return fmt.Sprintf("%s.%s €", beforeDecimal, afterDecimal)
+			formatted, err := FormatCurrency(currency, price)
+			if err != nil {
+				panic(err)
 			}
 
-			panic("Unknown currency: " + currency)
+			return formatted
 		},
 		// Formats a string unix time and prints it
 		"formatUnixTime": FormatUnixTime,
@@ -65,6 +65,27 @@ func MakeTemplateHelpers(t *template.Template) template.FuncMap {
 	}
 }
 
+// Formats a currency + price pair such as "EUR", "100" -> 1.00 €.
+func FormatCurrency(currency string, price string) (string, error) {
+	switch currency {
+	case "EUR":
+		// Prices are in cents, pad short ones so there's always a digit before the decimal point.
+		if price == "" {
+			return "", fmt.Errorf("empty price")
+		}
+
+		if len(price) < 3 {
+			price = strings.Repeat("0", 3-len(price)) + price
+		}
+
+		beforeDecimal := price[:len(price)-2]
+		afterDecimal := price[len(price)-2:]
+		return fmt.Sprintf("%s.%s €", beforeDecimal, afterDecimal), nil
+	}
+
+	return "", fmt.Errorf("Unknown currency: %s", currency)
+}
+
 var vtCsi = "\x1B["
 var vtCodes = map[string]string{
 	"reset":         vtCsi + "0m",
@@ -119,6 +140,61 @@ func FormatUnixTimeF(seconds interface{}, fmt string) string {
 	return reqTime.Format(fmt)
 }
 
+// Convert a unix time value returned by the API to a time.
+// The API is not consistent in the type of these values, so both numbers and numeric strings are accepted.
+// Returns false if there is no (non-zero) time.
+func ParseUnixTime(seconds interface{}) (time.Time, bool) {
+	var secs int64
+	switch value := reflect.ValueOf(seconds); value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		secs = value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		secs = int64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		secs = int64(value.Float())
+	case reflect.String:
+		parsed, err := strconv.ParseInt(value.String(), 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		secs = parsed
+	default:
+		return time.Time{}, false
+	}
+
+	if secs == 0 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(secs, 0), true
+}
+
+// Parse a period given in days, like "30d", "8w", "3m" or "1y".
+// Months count as 30 days and years as 365. A number without a unit is a number of days.
+func ParseDays(value string) (int, error) {
+	number := strings.TrimRight(value, "dwmyDWMY")
+	multiplier := 1
+	switch strings.ToLower(value[len(number):]) {
+	case "", "d":
+	case "w":
+		multiplier = 7
+	case "m":
+		multiplier = 30
+	case "y":
+		multiplier = 365
+	default:
+		return 0, fmt.Errorf("invalid period: '%s'", value)
+	}
+
+	days, err := strconv.Atoi(number)
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("invalid period: '%s'", value)
+	}
+
+	return days * multiplier, nil
+}
+
 // Format a unix time value returned by the API in a way that is human-readable.
 func FormatUnixTime(seconds interface{}) string {
 	result := FormatUnixTimeF(seconds, time.RFC1123)
@@ -0,0 +1,56 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/level27/lvl/utils"
+)
+
+func TestFormatCurrency(t *testing.T) {
+	tests := []struct {
+		price string
+		want  string
+	}{
+		{"1999", "19.99 €"},
+		{"100", "1.00 €"},
+		{"50", "0.50 €"},
+		{"5", "0.05 €"},
+	}
+
+	for _, test := range tests {
+		got, err := utils.FormatCurrency("EUR", test.price)
+		if err != nil || got != test.want {
+			t.Errorf("FormatCurrency(EUR, %s): got '%s', %v, want '%s'", test.price, got, err, test.want)
+		}
+	}
+
+	if _, err := utils.FormatCurrency("EUR", ""); err == nil {
+		t.Errorf("FormatCurrency(EUR, ''): expected error")
+	}
+
+	if _, err := utils.FormatCurrency("USD", "100"); err == nil {
+		t.Errorf("FormatCurrency(USD, 100): expected error")
+	}
+}
+
+func TestParseDays(t *testing.T) {
+	tests := map[string]int{
+		"45":  45,
+		"30d": 30,
+		"8w":  56,
+		"2m":  60,
+		"1Y":  365,
+	}
+
+	for value, want := range tests {
+		if got, err := utils.ParseDays(value); err != nil || got != want {
+			t.Errorf("ParseDays(%s): got %d, %v, want %d", value, got, err, want)
+		}
+	}
+
+	for _, value := range []string{"", "d", "2h", "1w2d", "-5d"} {
+		if _, err := utils.ParseDays(value); err == nil {
+			t.Errorf("ParseDays(%s): expected error", value)
+		}
+	}
+}
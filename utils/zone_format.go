@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+//
+// Canonical formatting of zone files.
+// The canonical layout is:
+// * $ORIGIN and $TTL directives at the top. Only the first $TTL is kept,
+//   records under a different $TTL get an explicit TTL instead.
+//   If there are records without TTL before the first $TTL, no $TTL is kept at all,
+//   so those records don't start using it.
+// * One record per line, with explicit owner name and class, aligned in columns.
+// * Owner names and domain names in record data are relative to $ORIGIN where possible.
+// * TTL values in plain seconds.
+// * SOA first, then the other records sorted by name, type and data.
+//
+// Comments are not preserved.
+//
+
+// Format the contents of a zone file in the canonical layout.
+// The contents must have an origin and no parse errors.
+func FormatZone(w io.Writer, contents ZoneContents) error {
+	if len(contents.Errors) != 0 {
+		return contents.Errors[0]
+	}
+
+	if contents.Origin == "" {
+		return errors.New("zone has no origin")
+	}
+
+	origin := contents.Origin
+	records := append([]ZoneRecord{}, contents.Records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		return zoneFormatLess(records[i], records[j], origin)
+	})
+
+	// Records without TTL before the first $TTL would get its TTL if it was moved to the top.
+	defaultTtl := contents.DefaultTtl
+	for _, record := range records {
+		if record.Ttl == nil && record.ZoneTtl == nil {
+			defaultTtl = nil
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "$ORIGIN %s\n", origin)
+	if defaultTtl != nil {
+		fmt.Fprintf(&buf, "$TTL %d\n", *defaultTtl)
+	}
+
+	fmt.Fprintln(&buf)
+
+	tw := tabwriter.NewWriter(&buf, 0, 8, 1, ' ', 0)
+	for _, record := range records {
+		ttl := ""
+		if record.Ttl != nil {
+			ttl = record.Ttl.String()
+		} else if record.ZoneTtl != nil && (defaultTtl == nil || *record.ZoneTtl != *defaultTtl) {
+			// At most one $TTL is written, records under any other $TTL need to keep their TTL explicitly.
+			ttl = record.ZoneTtl.String()
+		}
+
+		fmt.Fprintf(
+			tw,
+			"%s\t%s\t%s\t%s\t%s\n",
+			ZoneDomainRelative(record.Name, origin),
+			ttl,
+			record.Class,
+			record.Type,
+			strings.Join(zoneFormatData(record, origin), " "))
+	}
+
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func zoneFormatLess(a ZoneRecord, b ZoneRecord, origin string) bool {
+	// SOA always goes first.
+	if (a.Type == RecordTypeSOA) != (b.Type == RecordTypeSOA) {
+		return a.Type == RecordTypeSOA
+	}
+
+	nameA := zoneFormatSortName(a.Name, origin)
+	nameB := zoneFormatSortName(b.Name, origin)
+	if nameA != nameB {
+		return nameA < nameB
+	}
+
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+
+	return strings.Join(zoneFormatData(a, origin), " ") < strings.Join(zoneFormatData(b, origin), " ")
+}
+
+// Names are sorted hierarchically (by reversed labels), so the apex comes first
+// and subdomains follow the records of their parent.
+func zoneFormatSortName(name string, origin string) string {
+	labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+
+	sortName := strings.Join(labels, "\x00")
+	if !ZoneDomainInZone(name, origin) {
+		// Out-of-zone names go last.
+		return "\xff" + sortName
+	}
+
+	return sortName
+}
+
+// Get the canonical representation of every item in the record data.
+func zoneFormatData(record ZoneRecord, origin string) []string {
+	data := append([]string{}, record.Data...)
+	relative := func(idx int) {
+		if idx < len(data) {
+			data[idx] = ZoneDomainRelative(record.DataName(data[idx]), origin)
+		}
+	}
+
+	switch record.Type {
+	case RecordTypeCNAME, RecordTypeNS:
+		relative(0)
+	case RecordTypeMX:
+		relative(1)
+	case RecordTypeSRV:
+		relative(3)
+	case RecordTypeSOA:
+		relative(0)
+		relative(1)
+		for i := 2; i < len(data); i++ {
+			if ttl, err := ParseTtl(data[i]); err == nil {
+				data[i] = ttl.String()
+			}
+		}
+	case RecordTypeTXT:
+		for i := range data {
+			data[i] = ZoneQuoteString(data[i])
+		}
+	case RecordTypeCAA:
+		if len(data) == 3 {
+			data[1] = strings.ToLower(data[1])
+			data[2] = ZoneQuoteString(data[2])
+		}
+	case RecordTypeTLSA, RecordTypeDS:
+		// Hex data may be split over multiple items, join them back together.
+		if len(data) > 4 {
+			data = append(data[:3], strings.ToLower(strings.Join(data[3:], "")))
+		} else if len(data) == 4 {
+			data[3] = strings.ToLower(data[3])
+		}
+	case RecordTypeDNSKEY:
+		if len(data) > 4 {
+			data = append(data[:3], strings.Join(data[3:], ""))
+		}
+	}
+
+	return data
+}
+
+// Quote a string for use as a <character-string> in a zone file.
+func ZoneQuoteString(value string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		chr := value[i]
+		switch {
+		case chr == '"' || chr == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(chr)
+		case chr < 0x20 || chr == 0x7f:
+			fmt.Fprintf(&buf, "\\%03d", chr)
+		default:
+			buf.WriteByte(chr)
+		}
+	}
+	buf.WriteByte('"')
+
+	return buf.String()
+}
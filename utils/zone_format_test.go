@@ -0,0 +1,159 @@
+package utils_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/level27/lvl/utils"
+)
+
+func TestZoneFormat(t *testing.T) {
+	text := `$TTL 1d
+www.example.com.	1h	A	192.0.2.1
+@	IN	SOA	ns1.example.com. hostmaster.example.com. (
+    2022010101 ; serial
+    2h         ; refresh
+    1h         ; retry
+    2w         ; expire
+    1h )       ; minimum
+	MX	10 mail
+	NS	ns2.example.net.
+	NS	ns1.example.com.
+mail	IN	A	192.0.2.2
+$ORIGIN sub.example.com.
+api	CNAME	www.example.com.
+@	TXT	"v=spf1 -all" "quote\"d"
+`
+
+	expected := `$ORIGIN example.com.
+$TTL 86400
+
+@            IN SOA   ns1 hostmaster 2022010101 7200 3600 1209600 3600
+@            IN NS    ns1
+@            IN NS    ns2.example.net.
+@            IN MX    10 mail
+mail         IN A     192.0.2.2
+sub          IN TXT   "v=spf1 -all" "quote\"d"
+api.sub      IN CNAME www
+www     3600 IN A     192.0.2.1
+`
+
+	formatted := formatZone(t, text, "example.com.")
+	if formatted != expected {
+		t.Fatalf("Unexpected formatted zone. Expected:\n%s\nGot:\n%s", expected, formatted)
+	}
+
+	// Formatting must be idempotent.
+	again := formatZone(t, formatted, "")
+	if again != formatted {
+		t.Fatalf("Formatting is not idempotent. Got:\n%s", again)
+	}
+}
+
+func TestZoneFormatMultipleTtl(t *testing.T) {
+	text := `$ORIGIN example.com.
+$TTL 3600
+a	A	192.0.2.1
+$TTL 60
+b	A	192.0.2.2
+c	300	A	192.0.2.3
+$TTL 3600
+d	A	192.0.2.4
+`
+
+	expected := `$ORIGIN example.com.
+$TTL 3600
+
+a     IN A 192.0.2.1
+b 60  IN A 192.0.2.2
+c 300 IN A 192.0.2.3
+d     IN A 192.0.2.4
+`
+
+	formatted := formatZone(t, text, "")
+	if formatted != expected {
+		t.Fatalf("Unexpected formatted zone. Expected:\n%s\nGot:\n%s", expected, formatted)
+	}
+
+	again := formatZone(t, formatted, "")
+	if again != formatted {
+		t.Fatalf("Formatting is not idempotent. Got:\n%s", again)
+	}
+}
+
+func TestZoneFormatRecordsBeforeTtl(t *testing.T) {
+	text := `$ORIGIN example.com.
+a	A	192.0.2.1
+$TTL 3600
+b	A	192.0.2.2
+c	300	A	192.0.2.3
+`
+
+	// Moving $TTL to the top would give a its TTL.
+	expected := `$ORIGIN example.com.
+
+a      IN A 192.0.2.1
+b 3600 IN A 192.0.2.2
+c 300  IN A 192.0.2.3
+`
+
+	formatted := formatZone(t, text, "")
+	if formatted != expected {
+		t.Fatalf("Unexpected formatted zone. Expected:\n%s\nGot:\n%s", expected, formatted)
+	}
+
+	again := formatZone(t, formatted, "")
+	if again != formatted {
+		t.Fatalf("Formatting is not idempotent. Got:\n%s", again)
+	}
+}
+
+func TestParseTtl(t *testing.T) {
+	cases := map[string]utils.RecordTtl{
+		"0":     0,
+		"3600":  3600,
+		"1h":    3600,
+		"1H30m": 5400,
+		"2d":    172800,
+		"1w1d":  691200,
+	}
+
+	for input, expected := range cases {
+		ttl, err := utils.ParseTtl(input)
+		if err != nil {
+			t.Errorf("Unexpected error parsing '%s': %s", input, err)
+			continue
+		}
+
+		if ttl != expected {
+			t.Errorf("Unexpected TTL for '%s'. Expected %d, got %d", input, expected, ttl)
+		}
+	}
+
+	for _, input := range []string{"h", "1x", "99999999999"} {
+		if _, err := utils.ParseTtl(input); err == nil {
+			t.Errorf("Expected error parsing '%s'", input)
+		}
+	}
+}
+
+func formatZone(t *testing.T, text string, origin string) string {
+	var buf bytes.Buffer
+	err := utils.FormatZone(&buf, utils.ReadZone(strings.NewReader(text), origin))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestZoneFormatClass(t *testing.T) {
+	text := "$ORIGIN example.com.\nch CH TXT \"a\"\nhs HS TXT \"b\"\n"
+	expected := "$ORIGIN example.com.\n\nch  CH TXT \"a\"\nhs  HS TXT \"b\"\n"
+
+	formatted := formatZone(t, text, "")
+	if formatted != expected {
+		t.Fatalf("Unexpected formatted zone. Expected:\n%s\nGot:\n%s", expected, formatted)
+	}
+}
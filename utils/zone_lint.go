@@ -0,0 +1,353 @@
+package utils
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//
+// Offline validation of zone files.
+// References:
+// * RFC 1034 (section 3.6.2): CNAME records may not coexist with other data.
+// * RFC 2181 (section 10.3):  MX and NS targets may not be aliases.
+// * RFC 2782:                 SRV record format.
+// * RFC 6698 (section 2):     TLSA record format.
+// * RFC 4034 (section 2, 5):   DNSKEY and DS record format.
+// * RFC 8659 (section 4):     CAA record format.
+// * RFC 7208 (section 4.6.4): SPF DNS lookup limit.
+//
+
+type ZoneLintSeverity string
+
+const (
+	ZoneLintError   ZoneLintSeverity = "error"
+	ZoneLintWarning ZoneLintSeverity = "warning"
+)
+
+// A single problem found in a zone file.
+type ZoneLintIssue struct {
+	Line     int              `json:"line"`
+	Severity ZoneLintSeverity `json:"severity"`
+	Name     string           `json:"name"`
+	Type     string           `json:"type"`
+	Message  string           `json:"message"`
+}
+
+// Maximum amount of DNS-querying terms an SPF record may contain.
+const spfMaxLookups = 10
+
+// Maximum length of a single character-string in a TXT record.
+const txtMaxStringLength = 255
+
+// Lint a zone file. origin may be empty if the zone file contains an $ORIGIN directive.
+// Issues are returned sorted by line.
+func LintZone(reader io.Reader, origin string) []ZoneLintIssue {
+	contents := ReadZone(reader, origin)
+	issues := []ZoneLintIssue{}
+
+	for _, parseErr := range contents.Errors {
+		issues = append(issues, ZoneLintIssue{
+			Line:     parseErr.Line,
+			Severity: ZoneLintError,
+			Message:  parseErr.Err.Error(),
+		})
+	}
+
+	issues = append(issues, LintZoneRecords(contents.Origin, contents.Records)...)
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues
+}
+
+// Lint a set of already-read zone records. See LintZone.
+func LintZoneRecords(origin string, records []ZoneRecord) []ZoneLintIssue {
+	issues := []ZoneLintIssue{}
+	report := func(record ZoneRecord, severity ZoneLintSeverity, format string, args ...interface{}) {
+		issues = append(issues, ZoneLintIssue{
+			Line:     record.Line,
+			Severity: severity,
+			Name:     record.Name,
+			Type:     record.Type.String(),
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Index of which record types exist on every name, and where CNAMEs are.
+	namesTypes := map[string]map[RecordType]bool{}
+	for _, record := range records {
+		types, ok := namesTypes[record.Name]
+		if !ok {
+			types = map[RecordType]bool{}
+			namesTypes[record.Name] = types
+		}
+
+		types[record.Type] = true
+	}
+
+	isCname := func(name string) bool {
+		return namesTypes[name][RecordTypeCNAME]
+	}
+
+	seen := map[string]int{}
+	cnamesSeen := map[string]bool{}
+	spfSeen := map[string]bool{}
+
+	for _, record := range records {
+		if origin != "" && !ZoneDomainInZone(record.Name, origin) {
+			report(record, ZoneLintError, "name is outside of zone '%s'", origin)
+		}
+
+		key := zoneLintRecordKey(record)
+		if firstLine, ok := seen[key]; ok {
+			report(record, ZoneLintWarning, "duplicate record, first defined on line %d", firstLine)
+		} else {
+			seen[key] = record.Line
+		}
+
+		switch record.Type {
+		case RecordTypeCNAME:
+			if cnamesSeen[record.Name] {
+				report(record, ZoneLintError, "multiple CNAME records for the same name")
+			}
+			cnamesSeen[record.Name] = true
+
+			if len(namesTypes[record.Name]) > 1 {
+				report(record, ZoneLintError, "CNAME record coexists with other records for the same name")
+			}
+
+			if len(record.Data) != 1 {
+				report(record, ZoneLintError, "CNAME record must have exactly one target")
+			}
+
+		case RecordTypeMX:
+			if len(record.Data) != 2 {
+				report(record, ZoneLintError, "MX record must have a priority and a target")
+				continue
+			}
+
+			if _, err := strconv.ParseUint(record.Data[0], 10, 16); err != nil {
+				report(record, ZoneLintError, "invalid MX priority: '%s'", record.Data[0])
+			}
+
+			if isCname(record.DataName(record.Data[1])) {
+				report(record, ZoneLintError, "MX target '%s' is a CNAME", record.Data[1])
+			}
+
+		case RecordTypeNS:
+			if len(record.Data) != 1 {
+				report(record, ZoneLintError, "NS record must have exactly one target")
+				continue
+			}
+
+			if isCname(record.DataName(record.Data[0])) {
+				report(record, ZoneLintError, "NS target '%s' is a CNAME", record.Data[0])
+			}
+
+		case RecordTypeSRV:
+			for _, err := range lintSrvData(record) {
+				report(record, ZoneLintError, "%s", err)
+			}
+
+			if !strings.HasPrefix(record.Name, "_") {
+				report(record, ZoneLintWarning, "SRV record name does not start with _service._proto")
+			}
+
+		case RecordTypeCAA:
+			for _, err := range lintCaaData(record) {
+				report(record, ZoneLintError, "%s", err)
+			}
+
+		case RecordTypeTLSA:
+			for _, err := range lintTlsaData(record) {
+				report(record, ZoneLintError, "%s", err)
+			}
+
+		case RecordTypeDS:
+			ds, err := ParseDs(record.Data)
+			if err != nil {
+				report(record, ZoneLintError, "%s", err)
+				continue
+			}
+
+			for _, err := range ds.Validate() {
+				report(record, ZoneLintError, "%s", err)
+			}
+
+		case RecordTypeDNSKEY:
+			if _, err := ParseDnskey(record.Data); err != nil {
+				report(record, ZoneLintError, "%s", err)
+			}
+
+		case RecordTypeTXT:
+			for _, str := range record.Data {
+				if len(str) > txtMaxStringLength {
+					report(record, ZoneLintError, "TXT string is %d bytes long, maximum is %d. Split it into multiple quoted strings", len(str), txtMaxStringLength)
+				}
+			}
+
+			joined := strings.Join(record.Data, "")
+			if !IsSpfRecord(joined) {
+				continue
+			}
+
+			if spfSeen[record.Name] {
+				report(record, ZoneLintError, "multiple SPF records for the same name")
+			}
+			spfSeen[record.Name] = true
+
+			lookups := CountSpfLookups(joined)
+			if lookups > spfMaxLookups {
+				report(record, ZoneLintError, "SPF record needs %d DNS lookups, maximum is %d", lookups, spfMaxLookups)
+			}
+		}
+	}
+
+	return issues
+}
+
+// Key used to detect duplicate records.
+func zoneLintRecordKey(record ZoneRecord) string {
+	data := strings.Join(record.Data, "\x00")
+	if record.Type != RecordTypeTXT {
+		data = strings.ToLower(data)
+	}
+
+	return fmt.Sprintf("%s\x00%d\x00%d\x00%s", record.Name, record.Class, record.Type, data)
+}
+
+// SRV: <priority> <weight> <port> <target>
+func lintSrvData(record ZoneRecord) []string {
+	if len(record.Data) != 4 {
+		return []string{"SRV record must have priority, weight, port and target"}
+	}
+
+	errs := []string{}
+	for i, field := range []string{"priority", "weight", "port"} {
+		if _, err := strconv.ParseUint(record.Data[i], 10, 16); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid SRV %s: '%s'", field, record.Data[i]))
+		}
+	}
+
+	return errs
+}
+
+var caaKnownTags = []string{"issue", "issuewild", "iodef"}
+
+// CAA: <flags> <tag> <value>
+func lintCaaData(record ZoneRecord) []string {
+	if len(record.Data) != 3 {
+		return []string{"CAA record must have flags, tag and value"}
+	}
+
+	errs := []string{}
+	if _, err := strconv.ParseUint(record.Data[0], 10, 8); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid CAA flags: '%s'", record.Data[0]))
+	}
+
+	tag := record.Data[1]
+	if tag == "" || !isAsciiAlphanumeric(tag) {
+		errs = append(errs, fmt.Sprintf("invalid CAA tag: '%s'", tag))
+	} else if !sliceContainsString(caaKnownTags, strings.ToLower(tag)) {
+		errs = append(errs, fmt.Sprintf("unknown CAA tag: '%s'", tag))
+	}
+
+	return errs
+}
+
+// TLSA: <usage> <selector> <matching type> <certificate association data>
+func lintTlsaData(record ZoneRecord) []string {
+	if len(record.Data) < 4 {
+		return []string{"TLSA record must have usage, selector, matching type and certificate data"}
+	}
+
+	errs := []string{}
+	limits := []struct {
+		name string
+		max  uint64
+	}{{"usage", 3}, {"selector", 1}, {"matching type", 2}}
+
+	for i, limit := range limits {
+		value, err := strconv.ParseUint(record.Data[i], 10, 8)
+		if err != nil || value > limit.max {
+			errs = append(errs, fmt.Sprintf("invalid TLSA %s: '%s'", limit.name, record.Data[i]))
+		}
+	}
+
+	// Certificate data may be split over multiple items.
+	data := strings.Join(record.Data[3:], "")
+	decoded, err := hex.DecodeString(data)
+	if err != nil {
+		return append(errs, "TLSA certificate data is not valid hexadecimal")
+	}
+
+	switch record.Data[2] {
+	case "1":
+		if len(decoded) != 32 {
+			errs = append(errs, fmt.Sprintf("TLSA SHA-256 data must be 32 bytes, got %d", len(decoded)))
+		}
+	case "2":
+		if len(decoded) != 64 {
+			errs = append(errs, fmt.Sprintf("TLSA SHA-512 data must be 64 bytes, got %d", len(decoded)))
+		}
+	}
+
+	return errs
+}
+
+// Check whether the contents of a TXT record are an SPF policy.
+func IsSpfRecord(txt string) bool {
+	lower := strings.ToLower(txt)
+	return lower == "v=spf1" || strings.HasPrefix(lower, "v=spf1 ")
+}
+
+// Count the terms of an SPF record that cause DNS lookups when evaluated.
+// This does not follow include: or redirect= targets.
+func CountSpfLookups(spf string) int {
+	terms := strings.Fields(strings.ToLower(spf))
+	if len(terms) == 0 {
+		return 0
+	}
+
+	count := 0
+	for _, term := range terms[1:] {
+		// Strip qualifier.
+		term = strings.TrimLeft(term, "+-~?")
+
+		name := term
+		if idx := strings.IndexAny(term, ":/="); idx != -1 {
+			name = term[:idx]
+		}
+
+		switch name {
+		case "include", "a", "mx", "ptr", "exists", "redirect":
+			count += 1
+		}
+	}
+
+	return count
+}
+
+func isAsciiAlphanumeric(str string) bool {
+	for i := 0; i < len(str); i++ {
+		chr := str[i]
+		if !isAsciiDigit(chr) && !(chr >= 'a' && chr <= 'z') && !(chr >= 'A' && chr <= 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sliceContainsString(slice []string, value string) bool {
+	for _, opt := range slice {
+		if opt == value {
+			return true
+		}
+	}
+
+	return false
+}
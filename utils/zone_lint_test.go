@@ -0,0 +1,92 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/level27/lvl/utils"
+)
+
+func TestZoneLint(t *testing.T) {
+	text := `$ORIGIN example.com.
+$TTL 86400
+@	IN	SOA	ns1.example.com. hostmaster.example.com. ( 1 7200 3600 1209600 3600 )
+	NS	ns1
+	NS	alias
+@	MX	10 alias.example.com.
+www	A	192.0.2.1
+www	CNAME	web.example.net.
+alias	CNAME	www
+dup	A	192.0.2.2
+dup	A	192.0.2.2
+other.example.org.	A	192.0.2.3
+_sip._tcp	SRV	10 5 notaport sip.example.com.
+@	CAA	0 issue "letsencrypt.org"
+@	CAA	0 badtag! "letsencrypt.org"
+_443._tcp	TLSA	3 1 1 abcd
+long	TXT	"` + strings.Repeat("a", 256) + `"
+spf	TXT	"v=spf1 include:a.example include:b.example include:c.example include:d.example a mx ptr exists:e.example include:f.example include:g.example include:h.example -all"
+bad	A
+sub	DS	60485 8 2 abcd
+`
+
+	issues := utils.LintZone(strings.NewReader(text), "")
+
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{5, "NS target 'alias' is a CNAME"},
+		{6, "MX target 'alias.example.com.' is a CNAME"},
+		{8, "CNAME record coexists with other records for the same name"},
+		{11, "duplicate record, first defined on line 10"},
+		{12, "name is outside of zone 'example.com.'"},
+		{13, "invalid SRV port: 'notaport'"},
+		{15, "invalid CAA tag: 'badtag!'"},
+		{16, "TLSA SHA-256 data must be 32 bytes, got 2"},
+		{17, "TXT string is 256 bytes long, maximum is 255. Split it into multiple quoted strings"},
+		{18, "SPF record needs 11 DNS lookups, maximum is 10"},
+		{19, "failed reading second directive item: end of directive"},
+		{20, "DS SHA-256 digest must be 32 bytes, got 2"},
+	}
+
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %d: %v", len(expected), len(issues), issues)
+	}
+
+	for i, exp := range expected {
+		if issues[i].Line != exp.line || issues[i].Message != exp.message {
+			t.Errorf("Unexpected issue %d. Expected line %d: %s, got line %d: %s", i, exp.line, exp.message, issues[i].Line, issues[i].Message)
+		}
+	}
+}
+
+func TestZoneLintClean(t *testing.T) {
+	text := `$TTL 3600
+@	IN	SOA	ns1 hostmaster ( 1 7200 3600 1209600 3600 )
+	NS	ns1.example.net.
+	MX	10 mail
+mail	A	192.0.2.1
+www	CNAME	mail
+@	TXT	"v=spf1 mx -all"
+`
+
+	issues := utils.LintZone(strings.NewReader(text), "example.com.")
+	if len(issues) != 0 {
+		t.Fatal("Expected no issues, got:", issues)
+	}
+}
+
+func TestZoneLintTrailingComment(t *testing.T) {
+	issues := utils.LintZone(strings.NewReader("www A 192.0.2.1\n; no newline at the end"), "example.com.")
+	if len(issues) != 0 {
+		t.Fatal("Expected no issues, got:", issues)
+	}
+}
+
+func TestZoneLintNoOrigin(t *testing.T) {
+	issues := utils.LintZone(strings.NewReader("www A 192.0.2.1\n"), "")
+	if len(issues) != 1 || issues[0].Severity != utils.ZoneLintError {
+		t.Fatal("Expected a single error for relative name without origin, got:", issues)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -33,6 +34,56 @@ func (ttl RecordTtl) String() string {
 	return fmt.Sprint(uint32(ttl))
 }
 
+var ttlUnits = map[byte]uint64{
+	's': 1,
+	'm': 60,
+	'h': 60 * 60,
+	'd': 24 * 60 * 60,
+	'w': 7 * 24 * 60 * 60,
+}
+
+// Parse a TTL value. Next to plain seconds, this accepts BIND-style unit notation like "1h30m" or "2D".
+func ParseTtl(value string) (RecordTtl, error) {
+	if plain, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return RecordTtl(plain), nil
+	}
+
+	var total uint64
+	var current uint64
+	digits := 0
+	for i := 0; i < len(value); i++ {
+		chr := value[i]
+		if isAsciiDigit(chr) {
+			current = current*10 + uint64(chr-'0')
+			digits += 1
+			if current > math.MaxUint32 {
+				return 0, fmt.Errorf("TTL value out of range: '%s'", value)
+			}
+			continue
+		}
+
+		unit, ok := ttlUnits[chr|0x20]
+		if !ok || digits == 0 {
+			return 0, fmt.Errorf("invalid TTL value: '%s'", value)
+		}
+
+		total += current * unit
+		current = 0
+		digits = 0
+	}
+
+	if digits != 0 {
+		// Trailing number without unit.
+		total += current
+	}
+
+	if total > math.MaxUint32 {
+		return 0, fmt.Errorf("TTL value out of range: '%s'", value)
+	}
+
+	return RecordTtl(total), nil
+}
+
 type DnsClass uint16
 
 const (
@@ -44,7 +95,7 @@ const (
 var classMap = map[string]DnsClass{
 	"IN": DnsClassIN,
 	"CH": DnsClassCH,
-	"HS": DnsClassCH,
+	"HS": DnsClassHS,
 }
 
 var classMapReverse = reverseMap(classMap)
@@ -68,22 +119,25 @@ const (
 	RecordTypeAAAA RecordType = 28
 	RecordTypeSRV  RecordType = 33
 	RecordTypeDS   RecordType = 43
-	RecordTypeTLSA RecordType = 52
-	RecordTypeCAA  RecordType = 257
+	// DNSKEY records are served by Level27 when DNSSEC is enabled, but cannot be created.
+	RecordTypeDNSKEY RecordType = 48
+	RecordTypeTLSA   RecordType = 52
+	RecordTypeCAA    RecordType = 257
 )
 
 var typeMap = map[string]RecordType{
-	"A":     RecordTypeA,
-	"NS":    RecordTypeNS,
-	"CNAME": RecordTypeCNAME,
-	"SOA":   RecordTypeSOA,
-	"MX":    RecordTypeMX,
-	"TXT":   RecordTypeTXT,
-	"AAAA":  RecordTypeAAAA,
-	"SRV":   RecordTypeSRV,
-	"DS":    RecordTypeDS,
-	"TLSA":  RecordTypeTLSA,
-	"CAA":   RecordTypeCAA,
+	"A":      RecordTypeA,
+	"NS":     RecordTypeNS,
+	"CNAME":  RecordTypeCNAME,
+	"SOA":    RecordTypeSOA,
+	"MX":     RecordTypeMX,
+	"TXT":    RecordTypeTXT,
+	"AAAA":   RecordTypeAAAA,
+	"SRV":    RecordTypeSRV,
+	"DS":     RecordTypeDS,
+	"DNSKEY": RecordTypeDNSKEY,
+	"TLSA":   RecordTypeTLSA,
+	"CAA":    RecordTypeCAA,
 }
 
 var typeMapReverse = reverseMap(typeMap)
@@ -95,6 +149,22 @@ func (t RecordType) String() string {
 type ZoneParser struct {
 	reader    *bufio.Reader
 	lineIndex int32
+	entryLine int32
+}
+
+// Error produced when an entry in the zone file fails to parse.
+type ZoneParseError struct {
+	// Line (1-based) the offending directive started on.
+	Line int
+	Err  error
+}
+
+func (e ZoneParseError) Error() string {
+	return fmt.Sprintf("error on directive starting at line %d: %s", e.Line, e.Err)
+}
+
+func (e ZoneParseError) Unwrap() error {
+	return e.Err
 }
 
 // From https://www.reddit.com/r/golang/comments/q4a70y/how_do_experienced_go_developers_model_sum_types/
@@ -113,7 +183,7 @@ type ZoneEntryOrigin struct {
 func (ZoneEntryOrigin) IsZoneEntry() {}
 
 func (e ZoneEntryOrigin) String() string {
-	return fmt.Sprintf("$TTL %s;", e.DomainName)
+	return fmt.Sprintf("$ORIGIN %s;", e.DomainName)
 }
 
 // $INCLUDE zone file entry.
@@ -212,7 +282,7 @@ func (z *ZoneParser) NextEntry() (ZoneEntry, error) {
 				return nil, io.EOF
 			}
 
-			return nil, fmt.Errorf("error on directive starting at line %d: %s", startLine+1, err)
+			return nil, ZoneParseError{Line: int(startLine) + 1, Err: err}
 		}
 
 		if entry == nil {
@@ -228,6 +298,8 @@ func (z *ZoneParser) NextEntry() (ZoneEntry, error) {
 		break
 	}
 
+	z.entryLine = startLine
+
 	// Assuming a well-formed file, we should be at the end of a line (or EOF).
 	// For RRs this is guaranteed since they always try to parse as much items as possible.
 	// Special directives like $TTL expect a fixed count of items however,
@@ -240,11 +312,15 @@ func (z *ZoneParser) NextEntry() (ZoneEntry, error) {
 			return nil, err
 		}
 
-		return nil, fmt.Errorf("unexpected item found after directive starting at line %d: '%s'", startLine+1, item)
+		// Skip the rest of the line, so the next entry starts on a fresh line.
+		z.skipUntilEol()
+		z.skipNewlines()
+
+		return nil, ZoneParseError{Line: int(startLine) + 1, Err: fmt.Errorf("unexpected item found after directive: '%s'", item)}
 	}
 
 	if z.isInParentheses(state) {
-		return nil, fmt.Errorf("unclosed parentheses pair starting at line %d", *state.ParenthesesStartLine+1)
+		return nil, ZoneParseError{Line: int(startLine) + 1, Err: fmt.Errorf("unclosed parentheses pair starting at line %d", *state.ParenthesesStartLine+1)}
 	}
 
 	// Sanity assert we're right before a newline or EOL. If not, it's a bug in the parser.
@@ -267,6 +343,11 @@ func (z *ZoneParser) NextEntry() (ZoneEntry, error) {
 	return entry, nil
 }
 
+// Get the line (1-based) the entry last returned by NextEntry() started on.
+func (z *ZoneParser) Line() int {
+	return int(z.entryLine) + 1
+}
+
 // Core parsing code. Does not guarantee leaving read position in consistent state.
 func (z *ZoneParser) nextEntryCore(state *zoneEntryParseState) (ZoneEntry, error) {
 	// Starting directives or domain names MUST be at the hard start of the line.
@@ -283,6 +364,8 @@ func (z *ZoneParser) nextEntryCore(state *zoneEntryParseState) (ZoneEntry, error
 		switch keyItem {
 		case "$TTL":
 			return z.parseTtldirective(state)
+		case "$ORIGIN":
+			return z.parseOriginDirective(state)
 		}
 	}
 
@@ -296,13 +379,28 @@ func (z *ZoneParser) parseTtldirective(state *zoneEntryParseState) (ZoneEntry, e
 		return nil, err
 	}
 
-	ttl, err := strconv.ParseUint(valueItem, 10, 32)
+	ttl, err := ParseTtl(valueItem)
 	if err != nil {
 		return nil, err
 	}
 
 	return ZoneEntryTtl{
-		Ttl: RecordTtl(ttl),
+		Ttl: ttl,
+	}, nil
+}
+
+func (z *ZoneParser) parseOriginDirective(state *zoneEntryParseState) (ZoneEntry, error) {
+	valueItem, err := z.nextItem(state)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(valueItem, ".") {
+		return nil, fmt.Errorf("$ORIGIN must be an absolute domain name: '%s'", valueItem)
+	}
+
+	return ZoneEntryOrigin{
+		DomainName: valueItem,
 	}, nil
 }
 
@@ -320,6 +418,10 @@ func (z *ZoneParser) parseRrDirective(keyItem string, state *zoneEntryParseState
 			// Just return nil up the chain and let NextEntry() loop for the next line.
 			return nil, nil
 		}
+		if err == io.EOF && keyItem == "" {
+			// Same, but on the last line of a file that doesn't end with a newline (e.g. a trailing comment).
+			return nil, io.EOF
+		}
 		return nil, fmt.Errorf("failed reading first directive item: %v", err)
 	}
 
@@ -406,13 +508,12 @@ func (z *ZoneParser) checkFirstRecordItem(
 			return fmt.Errorf("found second number when TTL value already given: '%s'", item)
 		}
 
-		ttlVal, err := strconv.ParseUint(item, 10, 16)
+		ttlValue, err := ParseTtl(item)
 		if err != nil {
 			return fmt.Errorf("error parsing TTL value '%s': %s", item, err.Error())
 		}
 
-		ttlValue16 := RecordTtl(ttlVal)
-		*ttl = &ttlValue16
+		*ttl = &ttlValue
 		return nil
 	}
 
@@ -208,3 +208,16 @@ func ptrEq[T comparable](a *T, b *T) bool {
 
 	return *a == *b
 }
+
+func TestZoneParseRecoverAfterDirective(t *testing.T) {
+	text := "$TTL 300 garbage more\nfoo A 192.0.2.1\nbar A 192.0.2.2\n"
+	contents := utils.ReadZone(bytes.NewBufferString(text), "example.com.")
+
+	if len(contents.Errors) != 1 || contents.Errors[0].Line != 1 {
+		t.Fatalf("Expected a single error on line 1, got %v", contents.Errors)
+	}
+
+	if len(contents.Records) != 2 || contents.Records[0].Name != "foo.example.com." || contents.Records[1].Name != "bar.example.com." {
+		t.Fatalf("Expected both records after the error, got %+v", contents.Records)
+	}
+}
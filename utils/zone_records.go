@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+//
+// Resolving of zone file entries into self-contained records.
+// The parser leaves implicit state (previous owner name, $ORIGIN, class) up to using code,
+// this file takes care of tracking said state so tools like the linter can work on plain records.
+//
+
+// A resource record from a zone file, with all implicit zone file state resolved.
+type ZoneRecord struct {
+	// Line (1-based) the record started on in the zone file.
+	Line int
+	// Absolute, lower-cased owner name of the record.
+	Name string
+	// Origin that was active when the record was read, used to resolve relative names in Data.
+	Origin string
+	Class  DnsClass
+	// Can be nil to indicate not given for record
+	Ttl *RecordTtl
+	// Value of the $TTL directive that was active when the record was read, if any.
+	// This is the TTL of records that don't give their own.
+	ZoneTtl *RecordTtl
+	Type    RecordType
+	Data    []string
+}
+
+// The full contents of a zone file, as read by ReadZone.
+type ZoneContents struct {
+	// Origin of the zone. Either passed in explicitly or taken from the first $ORIGIN directive.
+	Origin string
+	// Value of the first $TTL directive, if any.
+	DefaultTtl *RecordTtl
+	Records    []ZoneRecord
+	Errors     []ZoneParseError
+}
+
+var errZoneNoOrigin = errors.New("relative name used without an origin")
+
+// Read all records from a zone file.
+// origin is the default origin for the zone, and may be empty if the zone file specifies its own $ORIGIN.
+// Parse errors do not abort reading, they are collected in the returned Errors instead.
+func ReadZone(reader io.Reader, origin string) ZoneContents {
+	contents := ZoneContents{Origin: strings.ToLower(origin)}
+
+	currentOrigin := contents.Origin
+	currentClass := DnsClassIN
+	var currentTtl *RecordTtl
+	lastDomain := "@"
+
+	parser := NewZoneParser(reader)
+	for {
+		entry, err := parser.NextEntry()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			if parseErr, ok := err.(ZoneParseError); ok {
+				contents.Errors = append(contents.Errors, parseErr)
+				continue
+			}
+
+			contents.Errors = append(contents.Errors, ZoneParseError{Line: parser.Line(), Err: err})
+			break
+		}
+
+		switch entry := entry.(type) {
+		case ZoneEntryTtl:
+			ttl := entry.Ttl
+			currentTtl = &ttl
+			if contents.DefaultTtl == nil {
+				contents.DefaultTtl = currentTtl
+			}
+
+		case ZoneEntryOrigin:
+			currentOrigin = strings.ToLower(entry.DomainName)
+			if contents.Origin == "" && len(contents.Records) == 0 {
+				contents.Origin = currentOrigin
+			}
+
+		case ZoneEntryRr:
+			if entry.DomainName != nil {
+				lastDomain = strings.ToLower(*entry.DomainName)
+			}
+
+			if entry.Class != nil {
+				currentClass = *entry.Class
+			}
+
+			if currentOrigin == "" && !strings.HasSuffix(lastDomain, ".") {
+				contents.Errors = append(contents.Errors, ZoneParseError{
+					Line: parser.Line(),
+					Err:  fmt.Errorf("%s: '%s'", errZoneNoOrigin, lastDomain),
+				})
+				continue
+			}
+
+			contents.Records = append(contents.Records, ZoneRecord{
+				Line:    parser.Line(),
+				Name:    ZoneDomainConcat(lastDomain, currentOrigin),
+				Origin:  currentOrigin,
+				Class:   currentClass,
+				Ttl:     entry.Ttl,
+				ZoneTtl: currentTtl,
+				Type:    entry.Type,
+				Data:    entry.Data,
+			})
+		}
+	}
+
+	return contents
+}
+
+// Resolve a (possibly relative) domain name in the record's data against the record's origin.
+func (r ZoneRecord) DataName(name string) string {
+	return ZoneDomainConcat(strings.ToLower(name), r.Origin)
+}
+
+// Get a normalized form of the record data, to compare records from different sources
+// (e.g. a zone file and a DNS response). Domain names in the data are made absolute and lower-cased.
+func (r ZoneRecord) DataKey() string {
+	data := append([]string{}, r.Data...)
+	absolute := func(idx int) {
+		if idx < len(data) {
+			data[idx] = r.DataName(data[idx])
+		}
+	}
+
+	switch r.Type {
+	case RecordTypeA, RecordTypeAAAA:
+		if len(data) == 1 {
+			if ip := net.ParseIP(data[0]); ip != nil {
+				data[0] = ip.String()
+			}
+		}
+	case RecordTypeCNAME, RecordTypeNS:
+		absolute(0)
+	case RecordTypeMX:
+		absolute(1)
+	case RecordTypeSRV:
+		absolute(3)
+	case RecordTypeSOA:
+		absolute(0)
+		absolute(1)
+	case RecordTypeTXT:
+		// How the text is split into strings doesn't matter.
+		return strings.Join(data, "")
+	case RecordTypeCAA:
+		if len(data) == 3 {
+			data[1] = strings.ToLower(data[1])
+		}
+	case RecordTypeTLSA, RecordTypeDS:
+		if len(data) > 3 {
+			data = append(data[:3], strings.ToLower(strings.Join(data[3:], "")))
+		}
+	case RecordTypeDNSKEY:
+		// Base64 is case-sensitive, only join the key.
+		if len(data) > 3 {
+			data = append(data[:3], strings.Join(data[3:], ""))
+		}
+	}
+
+	return strings.Join(data, " ")
+}
+
+// Make a domain absolute by appending the origin (if it's not yet absolute).
+func ZoneDomainConcat(domain string, origin string) string {
+	if strings.HasSuffix(domain, ".") {
+		return domain
+	}
+
+	if domain == "@" {
+		return origin
+	}
+
+	return fmt.Sprintf("%s.%s", domain, origin)
+}
+
+// Make a domain relative again by splitting off the
+// "xyz.foo.bar.baz.", "bar.baz." -> "xyz.foo"
+// "bar.baz.", "bar.baz."         -> "@"
+// "abc.xyz.", "bar.baz."         -> "abc.xyz."
+func ZoneDomainRelative(domain string, origin string) string {
+	if domain == origin {
+		// Same domain as origin
+		return "@"
+	}
+
+	if strings.HasSuffix(domain, fmt.Sprintf(".%s", origin)) {
+		// Subdomain of origin
+		return domain[:len(domain)-len(origin)-1]
+	}
+
+	// Not related at all
+	return domain
+}
+
+// Check whether a domain is equal to or a subdomain of the given zone origin.
+// Both domains must be absolute.
+func ZoneDomainInZone(domain string, origin string) bool {
+	return domain == origin || origin == "." || strings.HasSuffix(domain, "."+origin)
+}